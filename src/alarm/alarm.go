@@ -0,0 +1,188 @@
+package alarm
+
+import (
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"net/http"
+	"reflect"
+	"sync"
+	"time"
+
+	"hp90epc/config"
+	"hp90epc/model"
+)
+
+// DefaultDebounce: so lange nach einem Webhook bleibt dieselbe Regel stumm.
+const DefaultDebounce = 30 * time.Second
+
+// Status: Zustand einer Regel für /api/alarms/status.
+type Status struct {
+	Rule   config.AlarmRule `json:"rule"`
+	Active bool             `json:"active"`
+	// Since: letzter Wechsel von Active
+	Since     time.Time `json:"since"`
+	LastValue *float64  `json:"last_value"`
+	LastFired time.Time `json:"last_fired"`
+}
+
+// Payload: JSON-Body des Webhooks.
+type Payload struct {
+	Device string           `json:"device"`
+	Rule   config.AlarmRule `json:"rule"`
+	Value  float64          `json:"value"`
+	// Messwert wie angezeigt, vor der Umrechnung in die Einheit der Regel
+	ValueStr string    `json:"value_str"`
+	Unit     string    `json:"unit"`
+	Mode     string    `json:"mode"`
+	T        time.Time `json:"t"`
+}
+
+// Monitor prüft jede Messung gegen die Regeln und meldet Auslösungen per Webhook.
+// Ein flatternder Wert löst pro Regel höchstens einmal je Debounce-Fenster aus.
+type Monitor struct {
+	mu       sync.Mutex
+	device   string
+	rules    []config.AlarmRule
+	state    []Status
+	webhook  string
+	debounce time.Duration
+
+	client *http.Client
+}
+
+func NewMonitor(device string) *Monitor {
+	return &Monitor{
+		device:   device,
+		debounce: DefaultDebounce,
+		client:   &http.Client{Timeout: 5 * time.Second},
+	}
+}
+
+// SetDevice ändert die Geräte-ID im Webhook, z.B. nach einem Portwechsel.
+func (a *Monitor) SetDevice(id string) {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.device = id
+}
+
+// SetRules übernimmt Regeln, Webhook-URL (leer = nur Status) und Debounce (<= 0 = DefaultDebounce).
+// Unveränderte Regeln behalten ihren Zustand.
+func (a *Monitor) SetRules(rules []config.AlarmRule, webhook string, debounce time.Duration) {
+	if debounce <= 0 {
+		debounce = DefaultDebounce
+	}
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.webhook = webhook
+	a.debounce = debounce
+	if reflect.DeepEqual(rules, a.rules) {
+		return
+	}
+	a.rules = append([]config.AlarmRule(nil), rules...)
+	a.state = make([]Status, len(rules))
+	for i, r := range a.rules {
+		a.state[i].Rule = r
+	}
+}
+
+// Check wertet m aus; als LatestBuffer-Subscriber im Reader-Goroutine, der Webhook läuft asynchron.
+func (a *Monitor) Check(m *model.Measurement) {
+	if m == nil || m.Value == nil {
+		return
+	}
+	now := m.Timestamp
+	if now.IsZero() {
+		now = time.Now()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for i, r := range a.rules {
+		v, ok := ruleValue(r, m)
+		if !ok {
+			// andere Messgröße: Regel nicht auswertbar, Zustand bleibt
+			continue
+		}
+		st := &a.state[i]
+		st.LastValue = &v
+		hit := holds(r.Operator, v, r.Threshold)
+		switch {
+		case hit && !st.Active:
+			st.Active = true
+			st.Since = now
+			if now.Sub(st.LastFired) >= a.debounce {
+				st.LastFired = now
+				slog.Warn("alarm triggered", "device", a.device, "rule", r.Name, "value", v, "unit", r.Unit)
+				if a.webhook != "" {
+					go a.post(a.webhook, Payload{
+						Device: a.device, Rule: r, Value: v,
+						ValueStr: m.ValueStr, Unit: m.Unit, Mode: m.Mode, T: now,
+					})
+				}
+			}
+		case !hit && st.Active:
+			st.Active = false
+			st.Since = now
+			slog.Debug("alarm cleared", "device", a.device, "rule", r.Name, "value", v, "unit", r.Unit)
+		}
+	}
+}
+
+// Status liefert eine Kopie des Zustands aller Regeln.
+func (a *Monitor) Status() []Status {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	out := make([]Status, len(a.state))
+	copy(out, a.state)
+	return out
+}
+
+func (a *Monitor) post(url string, p Payload) {
+	b, err := json.Marshal(p)
+	if err != nil {
+		return
+	}
+	resp, err := a.client.Post(url, "application/json", bytes.NewReader(b))
+	if err != nil {
+		slog.Warn("alarm webhook", "err", err)
+		return
+	}
+	resp.Body.Close()
+	if resp.StatusCode >= 300 {
+		slog.Warn("alarm webhook", "status", resp.Status)
+	}
+}
+
+// ruleValue rechnet den Messwert (Basiseinheit) in die Einheit der Regel um, z.B. "mV"-Messung
+// gegen eine Schwelle in "V". Einheiten ohne Umrechnung (z.B. "%") müssen exakt passen.
+func ruleValue(r config.AlarmRule, m *model.Measurement) (float64, bool) {
+	if r.Mode != "" && r.Mode != m.Mode {
+		return 0, false
+	}
+	if v, err := model.Convert(*m.Value, m.Unit, r.Unit); err == nil {
+		return v, true
+	}
+	if r.Unit == m.Unit {
+		return *m.Value, true
+	}
+	return 0, false
+}
+
+func holds(op string, v, threshold float64) bool {
+	switch op {
+	case ">":
+		return v > threshold
+	case ">=":
+		return v >= threshold
+	case "<":
+		return v < threshold
+	case "<=":
+		return v <= threshold
+	case "==":
+		return v == threshold
+	case "!=":
+		return v != threshold
+	}
+	return false
+}
@@ -8,6 +8,9 @@ import (
 //go:embed ui/*
 var embeddedUI embed.FS
 
+//go:embed openapi.json
+var openAPI []byte
+
 func UI() fs.FS {
 	sub, err := fs.Sub(embeddedUI, "ui")
 	if err != nil {
@@ -17,3 +20,8 @@ func UI() fs.FS {
 	return sub
 }
 
+// OpenAPI liefert die von Hand gepflegte OpenAPI-3-Beschreibung der HTTP-API.
+// Bei neuen oder geänderten Endpunkten openapi.json mit anpassen.
+func OpenAPI() []byte {
+	return openAPI
+}
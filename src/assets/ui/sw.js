@@ -0,0 +1,37 @@
+// Service Worker: hält die UI-Hülle im Cache, damit das Dashboard auch ohne Server öffnet
+// (die Statusanzeige zeigt dann "Server weg"). API-Aufrufe gehen immer ans Netz.
+const CACHE = 'hp90epc-shell-v1';
+const SHELL = ['./', 'hp90epc.css', 'manifest.webmanifest', 'favicon.ico', 'icon-192.png', 'icon-512.png'];
+
+self.addEventListener('install', (e) => {
+    e.waitUntil(caches.open(CACHE).then((c) => c.addAll(SHELL)).then(() => self.skipWaiting()));
+});
+
+self.addEventListener('activate', (e) => {
+    e.waitUntil(
+        caches.keys()
+            .then((keys) => Promise.all(keys.filter((k) => k !== CACHE).map((k) => caches.delete(k))))
+            .then(() => self.clients.claim())
+    );
+});
+
+self.addEventListener('fetch', (e) => {
+    const req = e.request;
+    const url = new URL(req.url);
+    const scope = new URL(self.registration.scope);
+    if (req.method !== 'GET' || url.origin !== scope.origin || url.pathname.startsWith(scope.pathname + 'api/')) {
+        return;
+    }
+    // Netz zuerst (neue Versionen nach Update), Cache nur als Rückfall
+    e.respondWith(
+        fetch(req)
+            .then((res) => {
+                if (res.ok && SHELL.some((p) => new URL(p, scope).pathname === url.pathname)) {
+                    const copy = res.clone();
+                    caches.open(CACHE).then((c) => c.put(req, copy));
+                }
+                return res;
+            })
+            .catch(() => caches.match(req).then((hit) => hit || (req.mode === 'navigate' ? caches.match('./') : Response.error())))
+    );
+});
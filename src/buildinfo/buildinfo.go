@@ -0,0 +1,33 @@
+package buildinfo
+
+import (
+	"fmt"
+	"runtime"
+)
+
+// Werte werden beim Build per -ldflags "-X hp90epc/buildinfo.Version=..." gesetzt.
+var (
+	Version = "dev"
+	Commit  = "unknown"
+	Date    = "unknown"
+)
+
+type Info struct {
+	Version   string `json:"version"`
+	Commit    string `json:"commit"`
+	Date      string `json:"date"`
+	GoVersion string `json:"go_version"`
+}
+
+func Get() Info {
+	return Info{
+		Version:   Version,
+		Commit:    Commit,
+		Date:      Date,
+		GoVersion: runtime.Version(),
+	}
+}
+
+func (i Info) String() string {
+	return fmt.Sprintf("hp90epc %s (commit %s, built %s, %s)", i.Version, i.Commit, i.Date, i.GoVersion)
+}
@@ -0,0 +1,244 @@
+package client
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/url"
+	"strconv"
+	"strings"
+
+	"hp90epc/logging"
+	"hp90epc/model"
+	"hp90epc/reader"
+)
+
+// Options: optionale Einstellungen für New.
+type Options struct {
+	// HTTPClient: nil = http.DefaultClient
+	HTTPClient *http.Client
+	// Token für auth_token (Authorization: Bearer); alternativ BasicAuthUser/-Pass
+	Token         string
+	BasicAuthUser string
+	BasicAuthPass string
+}
+
+// Client spricht die HTTP-API eines laufenden Servers mit denselben Typen, die der Server ausliefert.
+// Sicher für gleichzeitige Nutzung.
+type Client struct {
+	base *url.URL
+	hc   *http.Client
+	opts Options
+	// dev: Geräte-ID für ?dev=, leer = Standardgerät
+	dev string
+}
+
+// Error: Antwort mit Fehlerstatus; Message ist der Text des Servers.
+type Error struct {
+	StatusCode int
+	Message    string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("hp90epc: %d %s: %s", e.StatusCode, http.StatusText(e.StatusCode), e.Message)
+}
+
+// IsNotFound meldet, ob err eine 404-Antwort ist (z.B. gelöschte Log-Datei, unbekanntes Gerät).
+func IsNotFound(err error) bool {
+	var e *Error
+	return errors.As(err, &e) && e.StatusCode == http.StatusNotFound
+}
+
+// New legt einen Client für baseURL an, z.B. "http://localhost:8080" oder "https://host/hp90epc".
+func New(baseURL string, opts Options) (*Client, error) {
+	u, err := url.Parse(baseURL)
+	if err != nil {
+		return nil, err
+	}
+	if u.Scheme != "http" && u.Scheme != "https" || u.Host == "" {
+		return nil, fmt.Errorf("invalid base URL %q (expected http(s)://host[:port][/path])", baseURL)
+	}
+	u.Path = strings.TrimSuffix(u.Path, "/")
+	hc := opts.HTTPClient
+	if hc == nil {
+		hc = http.DefaultClient
+	}
+	return &Client{base: u, hc: hc, opts: opts}, nil
+}
+
+// Device liefert einen Client, dessen gerätebezogene Aufrufe (Live, Status, Log-Dateien) an das Gerät id gehen.
+func (c *Client) Device(id string) *Client {
+	cp := *c
+	cp.dev = id
+	return &cp
+}
+
+// Live liefert die aktuelle Messung; nil ohne Fehler, solange der Reader nicht verbunden ist.
+func (c *Client) Live(ctx context.Context) (*model.Measurement, error) {
+	var m model.Measurement
+	ok, err := c.do(ctx, http.MethodGet, "/api/live", c.devQuery(), nil, &m)
+	if err != nil || !ok {
+		return nil, err
+	}
+	return &m, nil
+}
+
+// Status liefert den Reader-Status (Verbindung, Fehler, Signalqualität); ohne Device den des Standardgeräts.
+func (c *Client) Status(ctx context.Context) (reader.Status, error) {
+	if c.dev == "" {
+		all, err := c.Statuses(ctx)
+		if err != nil || len(all) == 0 {
+			return reader.Status{}, err
+		}
+		return all[0].Status, nil
+	}
+	var st reader.Status
+	_, err := c.do(ctx, http.MethodGet, "/api/reader/status", c.devQuery(), nil, &st)
+	return st, err
+}
+
+// DeviceStatus: ein Eintrag aus Statuses.
+type DeviceStatus struct {
+	ID string `json:"id"`
+	reader.Status
+}
+
+// Statuses liefert den Reader-Status aller Geräte, das Standardgerät zuerst.
+func (c *Client) Statuses(ctx context.Context) ([]DeviceStatus, error) {
+	var all []DeviceStatus
+	_, err := c.do(ctx, http.MethodGet, "/api/reader/status", nil, nil, &all)
+	return all, err
+}
+
+// SetDevice wechselt Port und Baudrate des Standardgeräts; baud 0 = 2400.
+func (c *Client) SetDevice(ctx context.Context, port string, baud int) (reader.Status, error) {
+	var st reader.Status
+	body := map[string]any{"port": port, "baud": baud}
+	_, err := c.do(ctx, http.MethodPost, "/api/device/port", nil, body, &st)
+	return st, err
+}
+
+// LogStatus liefert den Logging-Status.
+func (c *Client) LogStatus(ctx context.Context) (logging.LogStatus, error) {
+	return c.logCall(ctx, http.MethodGet, "/api/log/status")
+}
+
+// LogStart startet das Logging (alle Geräte).
+func (c *Client) LogStart(ctx context.Context) (logging.LogStatus, error) {
+	return c.logCall(ctx, http.MethodPost, "/api/log/start")
+}
+
+// LogStop beendet das Logging (alle Geräte).
+func (c *Client) LogStop(ctx context.Context) (logging.LogStatus, error) {
+	return c.logCall(ctx, http.MethodPost, "/api/log/stop")
+}
+
+func (c *Client) logCall(ctx context.Context, method, path string) (logging.LogStatus, error) {
+	var st logging.LogStatus
+	_, err := c.do(ctx, method, path, nil, nil, &st)
+	return st, err
+}
+
+// ListFiles liefert die Namen der Log-Dateien.
+func (c *Client) ListFiles(ctx context.Context) ([]string, error) {
+	var names []string
+	_, err := c.do(ctx, http.MethodGet, "/api/log/files", c.devQuery(), nil, &names)
+	return names, err
+}
+
+// ReadFile liefert den Inhalt einer Log-Datei (.gz entpackt); IsNotFound(err) bei fehlender Datei.
+func (c *Client) ReadFile(ctx context.Context, name string) ([]byte, error) {
+	var buf bytes.Buffer
+	_, err := c.do(ctx, http.MethodGet, "/api/log/file", c.fileQuery(name), nil, &buf)
+	return buf.Bytes(), err
+}
+
+// Tail liefert die letzten n Zeilen einer Log-Datei; n <= 0 = Server-Default (200).
+func (c *Client) Tail(ctx context.Context, name string, n int) ([]string, error) {
+	q := c.fileQuery(name)
+	if n > 0 {
+		q.Set("lines", strconv.Itoa(n))
+	}
+	var buf bytes.Buffer
+	if _, err := c.do(ctx, http.MethodGet, "/api/log/tail", q, nil, &buf); err != nil {
+		return nil, err
+	}
+	text := strings.TrimSuffix(buf.String(), "\n")
+	if text == "" {
+		return []string{}, nil
+	}
+	return strings.Split(text, "\n"), nil
+}
+
+// fileQuery: ?name= der Log-Datei, dazu ?dev= wie bei devQuery.
+func (c *Client) fileQuery(name string) url.Values {
+	q := url.Values{"name": {name}}
+	if c.dev != "" {
+		q.Set("dev", c.dev)
+	}
+	return q
+}
+
+func (c *Client) devQuery() url.Values {
+	if c.dev == "" {
+		return nil
+	}
+	return url.Values{"dev": {c.dev}}
+}
+
+// do führt einen Request aus. in wird als JSON gesendet (nil = ohne Body); out ist ein
+// *bytes.Buffer für Rohdaten oder ein JSON-Ziel. false ohne Fehler bei 204 No Content.
+func (c *Client) do(ctx context.Context, method, path string, q url.Values, in, out any) (bool, error) {
+	u := *c.base
+	u.Path += path
+	u.RawQuery = q.Encode()
+
+	var body io.Reader
+	if in != nil {
+		b, err := json.Marshal(in)
+		if err != nil {
+			return false, err
+		}
+		body = bytes.NewReader(b)
+	}
+	req, err := http.NewRequestWithContext(ctx, method, u.String(), body)
+	if err != nil {
+		return false, err
+	}
+	if in != nil {
+		req.Header.Set("Content-Type", "application/json")
+	}
+	if c.opts.Token != "" {
+		req.Header.Set("Authorization", "Bearer "+c.opts.Token)
+	} else if c.opts.BasicAuthUser != "" {
+		req.SetBasicAuth(c.opts.BasicAuthUser, c.opts.BasicAuthPass)
+	}
+
+	res, err := c.hc.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer res.Body.Close()
+
+	if res.StatusCode == http.StatusNoContent {
+		return false, nil
+	}
+	if res.StatusCode < 200 || res.StatusCode > 299 {
+		msg, _ := io.ReadAll(io.LimitReader(res.Body, 4096))
+		return false, &Error{StatusCode: res.StatusCode, Message: strings.TrimSpace(string(msg))}
+	}
+	if buf, ok := out.(*bytes.Buffer); ok {
+		_, err = buf.ReadFrom(res.Body)
+		return err == nil, err
+	}
+	if out != nil {
+		if err := json.NewDecoder(res.Body).Decode(out); err != nil {
+			return false, fmt.Errorf("decode %s: %w", path, err)
+		}
+	}
+	return true, nil
+}
@@ -0,0 +1,66 @@
+package config
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+)
+
+// SupportedBauds: Baudraten, die das HP-90EPC bzw. sein Schnittstellenkabel beherrscht.
+// Andere Raten öffnen den Port zwar, liefern aber nie einen Frame.
+var SupportedBauds = []int{600, 1200, 2400, 4800, 9600}
+
+// Baud: Baudrate, im JSON als Zahl oder als String ("2400", "9600 baud", "4.8k", "2400bps").
+type Baud int
+
+func (b *Baud) UnmarshalJSON(data []byte) error {
+	var s string
+	if err := json.Unmarshal(data, &s); err != nil {
+		var n int
+		if err := json.Unmarshal(data, &n); err != nil {
+			return fmt.Errorf("baud: expected number or string, got %s", data)
+		}
+		*b = Baud(n)
+		return nil
+	}
+	n, err := ParseBaud(s)
+	if err != nil {
+		return err
+	}
+	*b = Baud(n)
+	return nil
+}
+
+// ParseBaud liest "2400", "2400bps", "9600 baud" oder "4.8k"; leer = 0 (Default).
+func ParseBaud(s string) (int, error) {
+	t := strings.ToLower(strings.TrimSpace(s))
+	if t == "" {
+		return 0, nil
+	}
+	t = strings.TrimSpace(strings.TrimSuffix(strings.TrimSuffix(t, "bps"), "baud"))
+	mult := 1.0
+	if strings.HasSuffix(t, "k") {
+		mult, t = 1000, strings.TrimSpace(strings.TrimSuffix(t, "k"))
+	}
+	f, err := strconv.ParseFloat(t, 64)
+	n := int(f * mult)
+	if err != nil || f <= 0 || float64(n) != f*mult {
+		return 0, fmt.Errorf("invalid baud rate %q", s)
+	}
+	return n, nil
+}
+
+// CheckBaud meldet nicht unterstützte Raten mit der Liste der gültigen.
+func CheckBaud(baud int) error {
+	for _, b := range SupportedBauds {
+		if baud == b {
+			return nil
+		}
+	}
+	valid := make([]string, len(SupportedBauds))
+	for i, b := range SupportedBauds {
+		valid[i] = strconv.Itoa(b)
+	}
+	return fmt.Errorf("unsupported baud rate %d (supported: %s)", baud, strings.Join(valid, ", "))
+}
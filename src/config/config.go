@@ -3,30 +3,230 @@ package config
 import (
 	"encoding/json"
 	"errors"
+	"log/slog"
 	"os"
 	"path/filepath"
 	"runtime"
+	"strconv"
+	"strings"
 )
 
 const AppName = "hp90epc"
 
+// EnvPrefix: Umgebungsvariablen HP90EPC_* überschreiben Werte aus config.json (Flags gehen weiterhin vor).
+const EnvPrefix = "HP90EPC_"
+
 type Config struct {
+	// Name des zuletzt aktivierten Profils (profiles/<name>.json); config.json bleibt der aktive Stand
+	Profile string `json:"profile,omitempty"`
+
 	DevicePort string `json:"device_port"`
-	Baud       int    `json:"baud"`
+	Baud       Baud   `json:"baud"`
+	// weitere Messgeräte neben DevicePort (gleiches Protokoll); ID ist DeviceID(Port)
+	Devices []DeviceConfig `json:"devices,omitempty"`
+	// Frame-Protokoll des Geräts, siehe reader.ProtocolNames()
+	Protocol string `json:"protocol"`
+	// optionale Umbelegung der Dezimalpunkt-/Präfix-Bits für Gerätevarianten; nil = Standard
+	Decode *DecodeOptions `json:"decode,omitempty"`
+	// >0: höchstens so viele Messungen/s an Live-Puffer und Logger, die neueste gewinnt; 0 = aus
+	MaxFrameHz float64 `json:"max_frame_hz"`
+	// Größe des Lesepuffers im RunLoop in Bytes, mindestens eine Frame-Länge (14)
+	ReadBufferSize int `json:"read_buffer_size"`
+	// Schreibweise der Einheiten in Live-JSON und Logs: "Ohm" (Default), "Ω" oder "ohm";
+	// UnitASCII ersetzt zusätzlich µ durch u und °C durch degC
+	UnitOhm   string `json:"unit_ohm"`
+	UnitASCII bool   `json:"unit_ascii"`
+	// ohne Frames für diese Zeit gilt das Gerät als getrennt
+	StaleAfterMs int `json:"stale_after_ms"`
+	// /api/live liefert nach Ablauf von StaleAfterMs noch so lange den letzten Wert mit "stale": true; 0 = aus
+	LiveGraceMs int `json:"live_grace_ms"`
+	// false: Rohframe "raw" fehlt in Live-JSON (/api/live, SSE, Polling) und in neuen Log-Dateien;
+	// nil (fehlt in der Datei) = true wie bisher. /api/decode liefert raw immer.
+	IncludeRaw *bool `json:"include_raw,omitempty"`
+	// >0: Reader neu starten, wenn der offene Port so lange keine Frames liefert (hängender Treiber); 0 = aus
+	WatchdogTimeoutMs int `json:"watchdog_timeout_ms"`
+
+	LogDir        string `json:"log_dir"`
+	LogIntervalMs int    `json:"log_interval_ms"`
+	// Logging beim Programmstart einschalten; lief es beim letzten Ende noch, wird LogActiveFile fortgesetzt
+	LogAutoStart bool `json:"log_auto_start"`
+	// Zustand, kein Setting: Datei des laufenden Loggings, leer nach Stop über die API
+	LogActiveFile string `json:"log_active_file,omitempty"`
+	// 0 = keine Größen-Rotation
+	LogMaxFileBytes int64 `json:"log_max_file_bytes"`
+	// 0 = keine Zeit-Rotation, 60 = stündlich, 1440 = täglich
+	LogRotateMinutes int `json:"log_rotate_minutes"`
+	// >0: statt Rohdaten eine Zeile pro Fenster (min/max/mean/count); 0 = aus
+	LogAggregateSec int `json:"log_aggregate_sec"`
+	// "csv" oder "jsonl"
+	LogFormat string `json:"log_format"`
+	// "long": eine Zeile je Messung und Gerät; "wide": eine Zeile je Zeitpunkt mit Spalten je Gerät
+	LogLayout string `json:"log_layout"`
+	// Dateiname ohne Endung mit {ts}, {port}, {note}; unsichere Zeichen werden ersetzt
+	LogNamePattern string `json:"log_name_pattern"`
+	// Go-Zeitlayout der timestamp-Spalte
+	LogTimeFormat string `json:"log_time_format"`
+	// CSV-Feldtrenner (ein Zeichen) und Dezimalkomma; ";" + true = deutsches Excel
+	CSVDelimiter    string `json:"csv_delimiter"`
+	CSVDecimalComma bool   `json:"csv_decimal_comma"`
+	// Zahlenformat der value-Spalte: "g" (%g, Default) oder "fixed" mit LogFloatPrecision Nachkommastellen
+	LogFloatFormat    string `json:"log_float_format"`
+	LogFloatPrecision int    `json:"log_float_precision"`
+	// zusätzliche Spalte "display" mit Wert, Einheit und Modus in einem Feld
+	CSVDisplayColumn bool `json:"csv_display_column"`
+	// Kommentarzeile "# hp90epc …" (Version, Port, Baud, Start, Intervall) vor der Kopfzeile
+	CSVComment bool `json:"csv_comment"`
+	// abgeschlossene Log-Dateien (Stop, Rotation) zu <datei>.gz komprimieren
+	LogCompressClosed bool `json:"log_compress_closed"`
+	// Schreibpuffer: Flush nach N Zeilen und/oder spätestens nach ms; beide 0 = jede Zeile sofort
+	LogFlushRows int `json:"log_flush_rows"`
+	LogFlushMs   int `json:"log_flush_ms"`
+
+	HTTPAddr string `json:"http_addr"`
+	// UI und API unter einem Unterpfad, z.B. "/hp90epc" hinter einem Reverse-Proxy; leer = Wurzel
+	BasePath string `json:"base_path,omitempty"`
+	// Adresse, die beim Start unverändert im Browser geöffnet wird; leer = aus http_addr, TLS und base_path
+	OpenURL string `json:"open_url,omitempty"`
+	// HTTPS: Zertifikat und Schlüssel (PEM); alternativ TLSSelfSigned für ein beim Start erzeugtes Zertifikat
+	TLSCertFile   string `json:"tls_cert_file,omitempty"`
+	TLSKeyFile    string `json:"tls_key_file,omitempty"`
+	TLSSelfSigned bool   `json:"tls_self_signed,omitempty"`
+	// Timeouts des HTTP-Servers und Größenlimit für Request-Bodies; SSE, Long-Polling und Downloads
+	// sind von der Schreib-Timeout ausgenommen
+	HTTPReadTimeoutMs  int   `json:"http_read_timeout_ms"`
+	HTTPWriteTimeoutMs int   `json:"http_write_timeout_ms"`
+	HTTPIdleTimeoutMs  int   `json:"http_idle_timeout_ms"`
+	HTTPMaxBodyBytes   int64 `json:"http_max_body_bytes"`
+
+	// Log-Level der Diagnoseausgabe: debug, info, warn, error
+	LogLevel string `json:"log_level"`
 
-	LogDir     string `json:"log_dir"`
-	LogIntervalMs int  `json:"log_interval_ms"`
+	// Anzahl Messungen im Verlaufspuffer für /api/history
+	HistorySize int `json:"history_size"`
+	// Anzahl Funktionswechsel (Einheit/AC-DC) für /api/events; EventLogFile schreibt sie zusätzlich
+	// in events_<datum>.jsonl im Log-Verzeichnis
+	EventLogSize int  `json:"event_log_size"`
+	EventLogFile bool `json:"event_log_file"`
 
-	HTTPAddr   string `json:"http_addr"`
+	// optionaler Schutz der API: Bearer-Token und/oder Basic Auth
+	AuthToken     string `json:"auth_token,omitempty"`
+	BasicAuthUser string `json:"basic_auth_user,omitempty"`
+	BasicAuthPass string `json:"basic_auth_pass,omitempty"`
+	// true = auch lesende /api/*-Requests brauchen Auth
+	AuthProtectReads bool `json:"auth_protect_reads"`
+
+	// erlaubte Origins für CORS auf /api/*; leer = nur same-origin, "*" = alle
+	CORSOrigins []string `json:"cors_origins"`
+
+	// Grenzwert-Alarme: Auslösung wird per POST an AlarmWebhook gemeldet (leer = nur /api/alarms/status)
+	Alarms       []AlarmRule `json:"alarms,omitempty"`
+	AlarmWebhook string      `json:"alarm_webhook,omitempty"`
+	// dieselbe Regel meldet sich frühestens nach dieser Zeit erneut
+	AlarmDebounceMs int `json:"alarm_debounce_ms"`
+
+	// env: von applyEnv überschriebene Felder; Save schreibt dafür den Wert aus der Datei
+	env []envOverride
+}
+
+// envOverride setzt in c den Dateiwert eines Felds zurück, solange dort noch der Wert
+// aus der Umgebung steht. Über API oder Flags geänderte Werte bleiben unberührt.
+type envOverride func(c *Config)
+
+// fileValues liefert c ohne die Überschreibungen aus HP90EPC_*: Umgebungswerte, auch
+// HP90EPC_AUTH_TOKEN, landen so nie in config.json und fallen weg, sobald die Variable fehlt.
+func (c Config) fileValues() Config {
+	for _, restore := range c.env {
+		restore(&c)
+	}
+	c.env = nil
+	return c
+}
+
+// AlarmRule: löst aus, wenn der Messwert (in Unit umgerechnet) Operator Threshold erfüllt.
+// Mode leer = AC und DC.
+type AlarmRule struct {
+	Name      string  `json:"name"`
+	Unit      string  `json:"unit"`
+	Mode      string  `json:"mode,omitempty"`
+	Operator  string  `json:"operator"`
+	Threshold float64 `json:"threshold"`
+}
+
+// AlarmOperators: erlaubte Werte für AlarmRule.Operator.
+var AlarmOperators = []string{">", ">=", "<", "<=", "==", "!="}
+
+// DeviceConfig: ein zusätzliches Messgerät; Baud 0 = Baud der Hauptkonfiguration.
+type DeviceConfig struct {
+	Port string `json:"port"`
+	Baud Baud   `json:"baud,omitempty"`
+}
+
+// DeviceID leitet die Geräte-ID für ?dev= aus dem Port ab, z.B. "/dev/ttyUSB0" → "ttyUSB0",
+// "tcp://gw:4001" → "gw_4001" (auch als Log-Unterverzeichnis brauchbar).
+func DeviceID(port string) string {
+	if addr, ok := strings.CutPrefix(port, "tcp://"); ok {
+		return strings.NewReplacer(":", "_", "/", "_", "[", "", "]", "").Replace(addr)
+	}
+	return filepath.Base(filepath.Clean(port))
+}
+
+// BitRef: Bit im Frame, Byte-Index 0..13 und Bit 0..7 (0 = LSB).
+type BitRef struct {
+	Byte int `json:"byte"`
+	Bit  int `json:"bit"`
+}
+
+// DecodeOptions: nur gesetzte Felder überschreiben das eingebaute Mapping.
+type DecodeOptions struct {
+	// Dezimalpunkt für x.xxx, xx.xx, xxx.x – genau drei Einträge
+	DecimalPoints []BitRef `json:"decimal_points,omitempty"`
+
+	Nano  *BitRef `json:"nano,omitempty"`
+	Micro *BitRef `json:"micro,omitempty"`
+	Milli *BitRef `json:"milli,omitempty"`
+	Kilo  *BitRef `json:"kilo,omitempty"`
+	Mega  *BitRef `json:"mega,omitempty"`
+}
+
+// RawIncluded: IncludeRaw mit Default true.
+func (c Config) RawIncluded() bool {
+	return c.IncludeRaw == nil || *c.IncludeRaw
+}
+
+// Redacted liefert eine Kopie ohne Zugangsdaten, z.B. für GET /api/config.
+func (c Config) Redacted() Config {
+	c.AuthToken = ""
+	c.BasicAuthPass = ""
+	return c
 }
 
 func Default() Config {
 	c := Config{
-		DevicePort: defaultPortForOS(),
-		Baud:       2400,
-		LogDir:     "logs",
-		LogIntervalMs: 1000,
-		HTTPAddr:   ":8080",
+		DevicePort:     defaultPortForOS(),
+		Baud:           2400,
+		Protocol:       "hp90epc",
+		StaleAfterMs:   3000,
+		ReadBufferSize: 256,
+		LogDir:         "logs",
+		LogIntervalMs:  1000,
+		LogFormat:      "csv",
+		LogLayout:      "long",
+		UnitOhm:        "Ohm",
+		LogNamePattern: "hp90epc_{ts}",
+		LogTimeFormat:  "2006-01-02T15:04:05.000Z07:00",
+		CSVDelimiter:   ",",
+		LogFloatFormat: "g",
+		HTTPAddr:       ":8080",
+		LogLevel:       "info",
+		HistorySize:    600,
+		EventLogSize:   200,
+
+		AlarmDebounceMs: 30000,
+
+		HTTPReadTimeoutMs:  15000,
+		HTTPWriteTimeoutMs: 60000,
+		HTTPIdleTimeoutMs:  120000,
+		HTTPMaxBodyBytes:   1 << 20,
 	}
 	return c
 }
@@ -91,19 +291,26 @@ func Load(appDir string) (Config, error) {
 	path := ConfigPath(appDir)
 	b, err := os.ReadFile(path)
 	if err != nil {
+		c := Default()
 		if os.IsNotExist(err) {
-			c := Default()
 			// gleich schreiben, damit’s „greifbar“ ist
 			_ = Save(appDir, c)
+			applyEnv(&c)
+			repair(&c)
 			return c, nil
 		}
-		return Config{}, err
+		applyEnv(&c)
+		repair(&c)
+		return c, err
 	}
 
 	var c Config
 	if err := json.Unmarshal(b, &c); err != nil {
 		// kaputte Datei → Default liefern, aber Datei NICHT überschreiben
-		return Default(), err
+		c = Default()
+		applyEnv(&c)
+		repair(&c)
+		return c, err
 	}
 
 	// kleine Defaults für fehlende Felder
@@ -114,30 +321,170 @@ func Load(appDir string) (Config, error) {
 	if c.Baud == 0 {
 		c.Baud = def.Baud
 	}
+	if c.Protocol == "" {
+		c.Protocol = def.Protocol
+	}
+	if c.StaleAfterMs == 0 {
+		c.StaleAfterMs = def.StaleAfterMs
+	}
+	if c.ReadBufferSize == 0 {
+		c.ReadBufferSize = def.ReadBufferSize
+	}
 	if c.LogDir == "" {
 		c.LogDir = def.LogDir
 	}
-	if c.LogIntervalMs == 0 {
+	// 0 ist gültig (jeder Frame), nur ein fehlendes Feld bekommt den Default
+	if !hasKey(b, "log_interval_ms") {
 		c.LogIntervalMs = def.LogIntervalMs
 	}
 	if c.HTTPAddr == "" {
 		c.HTTPAddr = def.HTTPAddr
 	}
+	if c.HistorySize == 0 {
+		c.HistorySize = def.HistorySize
+	}
+	if c.EventLogSize == 0 {
+		c.EventLogSize = def.EventLogSize
+	}
+	if c.LogFormat == "" {
+		c.LogFormat = def.LogFormat
+	}
+	if c.LogLayout == "" {
+		c.LogLayout = def.LogLayout
+	}
+	if c.UnitOhm == "" {
+		c.UnitOhm = def.UnitOhm
+	}
+	if c.LogNamePattern == "" {
+		c.LogNamePattern = def.LogNamePattern
+	}
+	if c.LogTimeFormat == "" {
+		c.LogTimeFormat = def.LogTimeFormat
+	}
+	if c.CSVDelimiter == "" {
+		c.CSVDelimiter = def.CSVDelimiter
+	}
+	if c.LogFloatFormat == "" {
+		c.LogFloatFormat = def.LogFloatFormat
+	}
+	if c.LogLevel == "" {
+		c.LogLevel = def.LogLevel
+	}
+	if c.AlarmDebounceMs == 0 {
+		c.AlarmDebounceMs = def.AlarmDebounceMs
+	}
+	if c.HTTPReadTimeoutMs == 0 {
+		c.HTTPReadTimeoutMs = def.HTTPReadTimeoutMs
+	}
+	if c.HTTPWriteTimeoutMs == 0 {
+		c.HTTPWriteTimeoutMs = def.HTTPWriteTimeoutMs
+	}
+	if c.HTTPIdleTimeoutMs == 0 {
+		c.HTTPIdleTimeoutMs = def.HTTPIdleTimeoutMs
+	}
+	if c.HTTPMaxBodyBytes == 0 {
+		c.HTTPMaxBodyBytes = def.HTTPMaxBodyBytes
+	}
 
+	applyEnv(&c)
+	repair(&c)
 	return c, nil
 }
 
+// hasKey meldet, ob das JSON-Objekt b den Schlüssel key enthält (auch mit Nullwert).
+func hasKey(b []byte, key string) bool {
+	var m map[string]json.RawMessage
+	if err := json.Unmarshal(b, &m); err != nil {
+		return false
+	}
+	_, ok := m[key]
+	return ok
+}
+
+// applyEnv überschreibt Felder aus HP90EPC_*-Variablen; unlesbare Werte werden mit Warnung ignoriert.
+// Die Werte gelten nur im Speicher, Save schreibt die aus der Datei (siehe fileValues).
+func applyEnv(c *Config) {
+	str := func(name string, field func(*Config) *string) {
+		v := strings.TrimSpace(os.Getenv(EnvPrefix + name))
+		if v == "" {
+			return
+		}
+		file := *field(c)
+		*field(c) = v
+		c.env = append(c.env, func(d *Config) {
+			if *field(d) == v {
+				*field(d) = file
+			}
+		})
+	}
+	num := func(name string, field func(*Config) *int) {
+		v := strings.TrimSpace(os.Getenv(EnvPrefix + name))
+		if v == "" {
+			return
+		}
+		n, err := strconv.Atoi(v)
+		if err != nil {
+			slog.Warn("ignoring invalid environment override", "var", EnvPrefix+name, "value", v)
+			return
+		}
+		file := *field(c)
+		*field(c) = n
+		c.env = append(c.env, func(d *Config) {
+			if *field(d) == n {
+				*field(d) = file
+			}
+		})
+	}
+
+	str("PORT", func(c *Config) *string { return &c.DevicePort })
+	if v := strings.TrimSpace(os.Getenv(EnvPrefix + "BAUD")); v != "" {
+		if n, err := ParseBaud(v); err != nil {
+			slog.Warn("ignoring invalid environment override", "var", EnvPrefix+"BAUD", "value", v)
+		} else {
+			file := c.Baud
+			c.Baud = Baud(n)
+			c.env = append(c.env, func(d *Config) {
+				if d.Baud == Baud(n) {
+					d.Baud = file
+				}
+			})
+		}
+	}
+	str("PROTOCOL", func(c *Config) *string { return &c.Protocol })
+	str("HTTP_ADDR", func(c *Config) *string { return &c.HTTPAddr })
+	str("LOG_DIR", func(c *Config) *string { return &c.LogDir })
+	num("LOG_INTERVAL_MS", func(c *Config) *int { return &c.LogIntervalMs })
+	str("LOG_LEVEL", func(c *Config) *string { return &c.LogLevel })
+	str("AUTH_TOKEN", func(c *Config) *string { return &c.AuthToken })
+}
+
+// Save schreibt c nach config.json; Überschreibungen aus der Umgebung bleiben außen vor.
 func Save(appDir string, c Config) error {
+	c = c.fileValues()
+	if err := c.Validate(); err != nil {
+		return err
+	}
 	_ = os.MkdirAll(appDir, 0o755)
 
 	b, err := json.MarshalIndent(c, "", "  ")
 	if err != nil {
 		return err
 	}
-	tmp := ConfigPath(appDir) + ".tmp"
-	if err := os.WriteFile(tmp, b, 0o644); err != nil {
+	path := ConfigPath(appDir)
+	rememberSaved(path, b)
+	return writePrivate(path, b)
+}
+
+// writePrivate ersetzt path atomar über eine Zwischendatei mit Rechten 0600: config.json und
+// Profile enthalten Zugangsdaten (auth_token, basic_auth_pass), andere Benutzer lesen nicht mit.
+func writePrivate(path string, b []byte) error {
+	tmp := path + ".tmp"
+	if err := os.WriteFile(tmp, b, 0o600); err != nil {
+		return err
+	}
+	// WriteFile behält die Rechte einer liegen gebliebenen Zwischendatei
+	if err := os.Chmod(tmp, 0o600); err != nil {
 		return err
 	}
-	return os.Rename(tmp, ConfigPath(appDir))
+	return os.Rename(tmp, path)
 }
-
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"os"
+	"path/filepath"
+	"runtime"
+	"strings"
+	"testing"
+)
+
+func TestEnvOverridesStayInMemory(t *testing.T) {
+	dir := t.TempDir()
+	file := `{"device_port": "/dev/ttyUSB3", "baud": 2400, "log_interval_ms": 1000}`
+	if err := os.WriteFile(ConfigPath(dir), []byte(file), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	t.Setenv(EnvPrefix+"PORT", "/dev/ttyACM0")
+	t.Setenv(EnvPrefix+"BAUD", "9600")
+	t.Setenv(EnvPrefix+"LOG_INTERVAL_MS", "250")
+	t.Setenv(EnvPrefix+"AUTH_TOKEN", "s3cret")
+
+	c, err := Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DevicePort != "/dev/ttyACM0" || c.Baud != 9600 || c.LogIntervalMs != 250 || c.AuthToken != "s3cret" {
+		t.Fatalf("overrides not applied: %+v", c)
+	}
+
+	// ein per API geänderter Wert wird gespeichert, die übrigen Felder behalten den Dateiwert
+	c.LogIntervalMs = 500
+	if err := Save(dir, c); err != nil {
+		t.Fatal(err)
+	}
+	b, err := os.ReadFile(ConfigPath(dir))
+	if err != nil {
+		t.Fatal(err)
+	}
+	if strings.Contains(string(b), "s3cret") {
+		t.Error("config.json contains the token from the environment")
+	}
+
+	for _, name := range []string{"PORT", "BAUD", "LOG_INTERVAL_MS", "AUTH_TOKEN"} {
+		os.Unsetenv(EnvPrefix + name)
+	}
+	c, err = Load(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if c.DevicePort != "/dev/ttyUSB3" || c.Baud != 2400 || c.LogIntervalMs != 500 || c.AuthToken != "" {
+		t.Errorf("after unsetting the environment: port %q baud %d interval %d token %q, want file values and interval 500",
+			c.DevicePort, c.Baud, c.LogIntervalMs, c.AuthToken)
+	}
+}
+
+func TestSaveProfileWithoutEnvOverrides(t *testing.T) {
+	dir := t.TempDir()
+	t.Setenv(EnvPrefix+"AUTH_TOKEN", "s3cret")
+	c, _ := Load(dir)
+	if err := SaveProfile(dir, "bench", c); err != nil {
+		t.Fatal(err)
+	}
+	p, err := LoadProfile(dir, "bench")
+	if err != nil {
+		t.Fatal(err)
+	}
+	if p.AuthToken != "" {
+		t.Errorf("profile auth_token = %q, want the file value", p.AuthToken)
+	}
+}
+
+func TestSaveIsPrivate(t *testing.T) {
+	if runtime.GOOS == "windows" {
+		t.Skip("no Unix permissions")
+	}
+	dir := t.TempDir()
+	// Zwischendatei eines abgebrochenen Laufs mit offenen Rechten
+	if err := os.WriteFile(ConfigPath(dir)+".tmp", nil, 0o644); err != nil {
+		t.Fatal(err)
+	}
+	c := Default()
+	c.AuthToken = "s3cret"
+	if err := Save(dir, c); err != nil {
+		t.Fatal(err)
+	}
+	if err := SaveProfile(dir, "bench", c); err != nil {
+		t.Fatal(err)
+	}
+	for _, path := range []string{ConfigPath(dir), filepath.Join(ProfilesDir(dir), "bench.json")} {
+		fi, err := os.Stat(path)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if perm := fi.Mode().Perm(); perm != 0o600 {
+			t.Errorf("%s: mode %o, want 600", filepath.Base(path), perm)
+		}
+	}
+}
@@ -0,0 +1,98 @@
+package config
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io/fs"
+	"os"
+	"path/filepath"
+	"regexp"
+	"sort"
+	"strings"
+)
+
+var (
+	ErrInvalidProfile  = errors.New("invalid profile name")
+	ErrProfileNotFound = errors.New("profile not found")
+)
+
+var profileNameRe = regexp.MustCompile(`^[A-Za-z0-9_-]{1,64}$`)
+
+// ProfilesDir: benannte Profile liegen als profiles/<name>.json neben config.json.
+func ProfilesDir(appDir string) string {
+	return filepath.Join(appDir, "profiles")
+}
+
+func profilePath(appDir, name string) (string, error) {
+	if !profileNameRe.MatchString(name) {
+		return "", fmt.Errorf("%w: %q (allowed: A-Z a-z 0-9 _ -)", ErrInvalidProfile, name)
+	}
+	return filepath.Join(ProfilesDir(appDir), name+".json"), nil
+}
+
+// ListProfiles liefert die Namen aller gespeicherten Profile, sortiert.
+func ListProfiles(appDir string) ([]string, error) {
+	entries, err := os.ReadDir(ProfilesDir(appDir))
+	if errors.Is(err, fs.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	out := []string{}
+	for _, e := range entries {
+		name, ok := strings.CutSuffix(e.Name(), ".json")
+		if !e.IsDir() && ok && profileNameRe.MatchString(name) {
+			out = append(out, name)
+		}
+	}
+	sort.Strings(out)
+	return out, nil
+}
+
+// LoadProfile liest ein Profil; fehlende Felder kommen aus den Defaults.
+func LoadProfile(appDir, name string) (Config, error) {
+	path, err := profilePath(appDir, name)
+	if err != nil {
+		return Config{}, err
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, fs.ErrNotExist) {
+		return Config{}, fmt.Errorf("%w: %s", ErrProfileNotFound, name)
+	}
+	if err != nil {
+		return Config{}, err
+	}
+	c := Default()
+	if err := json.Unmarshal(b, &c); err != nil {
+		return Config{}, fmt.Errorf("profile %s: %w", name, err)
+	}
+	c.Profile = name
+	if err := c.Validate(); err != nil {
+		return Config{}, fmt.Errorf("profile %s: %w", name, err)
+	}
+	return c, nil
+}
+
+// SaveProfile legt ein Profil an oder überschreibt es.
+func SaveProfile(appDir, name string, c Config) error {
+	path, err := profilePath(appDir, name)
+	if err != nil {
+		return err
+	}
+	// wie Save: Werte aus HP90EPC_* gehören nicht ins Profil
+	c = c.fileValues()
+	c.Profile = name
+	if err := c.Validate(); err != nil {
+		return err
+	}
+	if err := os.MkdirAll(ProfilesDir(appDir), 0o755); err != nil {
+		return err
+	}
+	b, err := json.MarshalIndent(c, "", "  ")
+	if err != nil {
+		return err
+	}
+	return writePrivate(path, b)
+}
@@ -0,0 +1,265 @@
+package config
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"math"
+	"net"
+	"net/url"
+	"reflect"
+	"slices"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+)
+
+// FieldError benennt das ungültige Feld über seinen JSON-Namen, damit die UI es markieren kann.
+type FieldError struct {
+	Field string
+	Msg   string
+}
+
+func (e *FieldError) Error() string {
+	return e.Field + ": " + e.Msg
+}
+
+// Validate prüft die Werte auf Plausibilität; alle Fehler werden gesammelt (errors.Join von *FieldError).
+func (c Config) Validate() error {
+	var errs []error
+	bad := func(field, format string, args ...any) {
+		errs = append(errs, &FieldError{Field: field, Msg: fmt.Sprintf(format, args...)})
+	}
+
+	if strings.TrimSpace(c.DevicePort) == "" {
+		bad("device_port", "required")
+	} else if err := validTCPPort(c.DevicePort); err != nil {
+		bad("device_port", "%v", err)
+	}
+	if err := CheckBaud(int(c.Baud)); err != nil {
+		bad("baud", "%v", err)
+	}
+	ids := map[string]bool{DeviceID(c.DevicePort): true}
+	for i, d := range c.Devices {
+		field := fmt.Sprintf("devices[%d]", i)
+		switch {
+		case strings.TrimSpace(d.Port) == "":
+			bad(field, "port required")
+		case d.Baud != 0 && CheckBaud(int(d.Baud)) != nil:
+			bad(field, "%v", CheckBaud(int(d.Baud)))
+		case ids[DeviceID(d.Port)]:
+			bad(field, "duplicate device id %q", DeviceID(d.Port))
+		default:
+			if err := validTCPPort(d.Port); err != nil {
+				bad(field, "%v", err)
+			}
+		}
+		ids[DeviceID(d.Port)] = true
+	}
+	if c.Decode != nil {
+		if err := c.Decode.validate(); err != nil {
+			bad("decode", "%v", err)
+		}
+	}
+	if c.MaxFrameHz < 0 || math.IsNaN(c.MaxFrameHz) || math.IsInf(c.MaxFrameHz, 0) {
+		bad("max_frame_hz", "must be >= 0 (0 = off), got %v", c.MaxFrameHz)
+	}
+	// kleiner als ein Frame (14 Bytes) wäre sinnlos, nach oben nur eine Plausibilitätsgrenze
+	if c.ReadBufferSize < 14 || c.ReadBufferSize > 65536 {
+		bad("read_buffer_size", "must be 14..65536, got %d", c.ReadBufferSize)
+	}
+	switch c.UnitOhm {
+	case "Ohm", "ohm":
+	case "Ω":
+		if c.UnitASCII {
+			bad("unit_ohm", "%q is not ASCII, use Ohm or ohm with unit_ascii", c.UnitOhm)
+		}
+	default:
+		bad("unit_ohm", "must be Ohm, Ω or ohm, got %q", c.UnitOhm)
+	}
+	if c.StaleAfterMs <= 0 {
+		bad("stale_after_ms", "must be > 0, got %d", c.StaleAfterMs)
+	}
+	if c.LiveGraceMs < 0 {
+		bad("live_grace_ms", "must be >= 0, got %d", c.LiveGraceMs)
+	}
+	if c.WatchdogTimeoutMs != 0 && (c.WatchdogTimeoutMs < 5000 || c.WatchdogTimeoutMs < c.StaleAfterMs) {
+		bad("watchdog_timeout_ms", "must be 0 (off) or >= 5000 and >= stale_after_ms, got %d", c.WatchdogTimeoutMs)
+	}
+	if strings.TrimSpace(c.LogDir) == "" {
+		bad("log_dir", "required")
+	}
+	if c.LogIntervalMs < 0 {
+		bad("log_interval_ms", "must be >= 0 (0 = every frame), got %d", c.LogIntervalMs)
+	}
+	if c.LogMaxFileBytes < 0 {
+		bad("log_max_file_bytes", "must be >= 0 (0 = off), got %d", c.LogMaxFileBytes)
+	}
+	if c.LogRotateMinutes < 0 {
+		bad("log_rotate_minutes", "must be >= 0 (0 = off), got %d", c.LogRotateMinutes)
+	}
+	if c.LogAggregateSec < 0 {
+		bad("log_aggregate_sec", "must be >= 0 (0 = off), got %d", c.LogAggregateSec)
+	}
+	if c.LogFlushRows < 0 {
+		bad("log_flush_rows", "must be >= 0, got %d", c.LogFlushRows)
+	}
+	if c.LogFlushMs < 0 {
+		bad("log_flush_ms", "must be >= 0, got %d", c.LogFlushMs)
+	}
+	if strings.TrimSpace(c.LogNamePattern) == "" {
+		bad("log_name_pattern", "required (e.g. hp90epc_{ts})")
+	} else if strings.ContainsAny(c.LogNamePattern, `/\`) {
+		bad("log_name_pattern", "must not contain path separators, got %q", c.LogNamePattern)
+	}
+	if c.LogFormat != "csv" && c.LogFormat != "jsonl" {
+		bad("log_format", "must be csv or jsonl, got %q", c.LogFormat)
+	}
+	if c.LogLayout != "long" && c.LogLayout != "wide" {
+		bad("log_layout", "must be long or wide, got %q", c.LogLayout)
+	}
+	if r, n := utf8.DecodeRuneInString(c.CSVDelimiter); n == 0 || n != len(c.CSVDelimiter) ||
+		r == utf8.RuneError || r == '"' || r == '\r' || r == '\n' {
+		bad("csv_delimiter", "must be a single character other than quote or newline, got %q", c.CSVDelimiter)
+	}
+	if c.LogFloatFormat != "g" && c.LogFloatFormat != "fixed" {
+		bad("log_float_format", "must be g or fixed, got %q", c.LogFloatFormat)
+	}
+	if c.LogFloatPrecision < 0 || c.LogFloatPrecision > 12 {
+		bad("log_float_precision", "must be between 0 and 12, got %d", c.LogFloatPrecision)
+	} else if c.LogFloatFormat == "fixed" && c.LogFloatPrecision == 0 {
+		// am Format melden: repair fällt dann auf %g zurück statt auf Ganzzahlen
+		bad("log_float_format", "fixed requires log_float_precision between 1 and 12")
+	}
+	if (c.TLSCertFile == "") != (c.TLSKeyFile == "") {
+		bad("tls_cert_file", "tls_cert_file and tls_key_file must be set together")
+	}
+	if err := validListenAddr(c.HTTPAddr); err != nil {
+		bad("http_addr", "%v", err)
+	}
+	if err := new(slog.Level).UnmarshalText([]byte(c.LogLevel)); err != nil {
+		bad("log_level", "must be debug, info, warn or error, got %q", c.LogLevel)
+	}
+	if c.HistorySize <= 0 {
+		bad("history_size", "must be > 0, got %d", c.HistorySize)
+	}
+	if c.EventLogSize <= 0 {
+		bad("event_log_size", "must be > 0, got %d", c.EventLogSize)
+	}
+	for i, r := range c.Alarms {
+		field := fmt.Sprintf("alarms[%d]", i)
+		switch {
+		case strings.TrimSpace(r.Unit) == "":
+			bad(field, "unit required")
+		case !slices.Contains(AlarmOperators, r.Operator):
+			bad(field, "operator must be one of %v, got %q", AlarmOperators, r.Operator)
+		case math.IsNaN(r.Threshold) || math.IsInf(r.Threshold, 0):
+			bad(field, "threshold must be a finite number")
+		}
+	}
+	if c.AlarmWebhook != "" {
+		if u, err := url.Parse(c.AlarmWebhook); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("alarm_webhook", "must be an http(s) URL, got %q", c.AlarmWebhook)
+		}
+	}
+	if c.AlarmDebounceMs <= 0 {
+		bad("alarm_debounce_ms", "must be > 0, got %d", c.AlarmDebounceMs)
+	}
+	for _, t := range []struct {
+		field string
+		ms    int
+	}{
+		{"http_read_timeout_ms", c.HTTPReadTimeoutMs},
+		{"http_write_timeout_ms", c.HTTPWriteTimeoutMs},
+		{"http_idle_timeout_ms", c.HTTPIdleTimeoutMs},
+	} {
+		if t.ms < 1000 {
+			bad(t.field, "must be >= 1000, got %d", t.ms)
+		}
+	}
+	if c.BasePath != "" && (!strings.HasPrefix(c.BasePath, "/") || strings.Contains(c.BasePath, "..") ||
+		strings.ContainsAny(c.BasePath, "?#\\ ")) {
+		bad("base_path", "must be a URL path like /hp90epc, got %q", c.BasePath)
+	}
+	if c.OpenURL != "" {
+		if u, err := url.Parse(c.OpenURL); err != nil || (u.Scheme != "http" && u.Scheme != "https") || u.Host == "" {
+			bad("open_url", "must be an http(s) URL, got %q", c.OpenURL)
+		}
+	}
+	if c.HTTPMaxBodyBytes < 1024 {
+		bad("http_max_body_bytes", "must be >= 1024, got %d", c.HTTPMaxBodyBytes)
+	}
+	return errors.Join(errs...)
+}
+
+func (d *DecodeOptions) validate() error {
+	if d.DecimalPoints != nil && len(d.DecimalPoints) != 3 {
+		return fmt.Errorf("decimal_points needs exactly 3 entries, got %d", len(d.DecimalPoints))
+	}
+	refs := map[string]*BitRef{"nano": d.Nano, "micro": d.Micro, "milli": d.Milli, "kilo": d.Kilo, "mega": d.Mega}
+	for i := range d.DecimalPoints {
+		refs[fmt.Sprintf("decimal_points[%d]", i)] = &d.DecimalPoints[i]
+	}
+	for name, r := range refs {
+		if r != nil && (r.Byte < 0 || r.Byte > 13 || r.Bit < 0 || r.Bit > 7) {
+			return fmt.Errorf("%s: byte must be 0..13 and bit 0..7, got byte %d bit %d", name, r.Byte, r.Bit)
+		}
+	}
+	return nil
+}
+
+func validListenAddr(addr string) error {
+	if path, ok := strings.CutPrefix(addr, "unix:"); ok {
+		if path == "" {
+			return errors.New("unix socket path required (unix:/path/to.sock)")
+		}
+		return nil
+	}
+	_, port, err := net.SplitHostPort(addr)
+	if err != nil {
+		return fmt.Errorf("invalid listen address %q (expected host:port, :port or unix:/path)", addr)
+	}
+	if n, err := strconv.Atoi(port); err != nil || n < 0 || n > 65535 {
+		return fmt.Errorf("invalid port %q", port)
+	}
+	return nil
+}
+
+// validTCPPort prüft Ports der Form tcp://host:port (Seriell-Ethernet-Gateway); lokale Pfade passieren.
+func validTCPPort(port string) error {
+	addr, ok := strings.CutPrefix(port, "tcp://")
+	if !ok {
+		return nil
+	}
+	host, p, err := net.SplitHostPort(addr)
+	if err != nil || host == "" {
+		return fmt.Errorf("invalid network port %q (expected tcp://host:port)", port)
+	}
+	if n, err := strconv.Atoi(p); err != nil || n <= 0 || n > 65535 {
+		return fmt.Errorf("invalid tcp port %q", p)
+	}
+	return nil
+}
+
+// repair ersetzt ungültige Felder durch ihre Defaults und meldet das als Warnung.
+func repair(c *Config) {
+	err := c.Validate()
+	if err == nil {
+		return
+	}
+	def := reflect.ValueOf(Default())
+	v := reflect.ValueOf(c).Elem()
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fe *FieldError
+		if !errors.As(e, &fe) {
+			continue
+		}
+		for i := 0; i < v.NumField(); i++ {
+			tag, _, _ := strings.Cut(v.Type().Field(i).Tag.Get("json"), ",")
+			if tag == fe.Field {
+				slog.Warn("invalid config value, using default", "field", fe.Field, "err", fe.Msg)
+				v.Field(i).Set(def.Field(i))
+			}
+		}
+	}
+}
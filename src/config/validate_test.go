@@ -0,0 +1,72 @@
+package config
+
+import (
+	"errors"
+	"os"
+	"testing"
+)
+
+// fieldErrors liefert die JSON-Namen aller ungültigen Felder.
+func fieldErrors(err error) map[string]bool {
+	out := map[string]bool{}
+	if err == nil {
+		return out
+	}
+	for _, e := range err.(interface{ Unwrap() []error }).Unwrap() {
+		var fe *FieldError
+		if errors.As(e, &fe) {
+			out[fe.Field] = true
+		}
+	}
+	return out
+}
+
+func TestValidateReadBufferSize(t *testing.T) {
+	if err := Default().Validate(); err != nil {
+		t.Fatalf("default config invalid: %v", err)
+	}
+	tests := []struct {
+		size int
+		ok   bool
+	}{
+		{0, false},
+		{13, false},
+		{14, true},
+		{256, true},
+		{65536, true},
+		{65537, false},
+		{-1, false},
+	}
+	for _, tt := range tests {
+		c := Default()
+		c.ReadBufferSize = tt.size
+		if got := !fieldErrors(c.Validate())["read_buffer_size"]; got != tt.ok {
+			t.Errorf("read_buffer_size %d: valid = %v, want %v", tt.size, got, tt.ok)
+		}
+	}
+}
+
+func TestLoadReadBufferSize(t *testing.T) {
+	tests := []struct {
+		json string
+		want int
+	}{
+		{`{}`, 256},
+		{`{"read_buffer_size": 4096}`, 4096},
+		// zu klein: repair setzt den Default ein
+		{`{"read_buffer_size": 8}`, 256},
+	}
+	for _, tt := range tests {
+		dir := t.TempDir()
+		if err := os.WriteFile(ConfigPath(dir), []byte(tt.json), 0o644); err != nil {
+			t.Fatal(err)
+		}
+		c, err := Load(dir)
+		if err != nil {
+			t.Fatalf("%s: %v", tt.json, err)
+		}
+		if c.ReadBufferSize != tt.want {
+			t.Errorf("%s: read_buffer_size = %d, want %d", tt.json, c.ReadBufferSize, tt.want)
+		}
+	}
+}
@@ -0,0 +1,77 @@
+package config
+
+import (
+	"context"
+	"crypto/sha256"
+	"log/slog"
+	"os"
+	"sync"
+	"time"
+)
+
+// Prüfsumme des zuletzt von Save geschriebenen Inhalts je Pfad: eigene Writes lösen keinen Reload aus.
+var (
+	savedMu  sync.Mutex
+	savedSum = map[string][sha256.Size]byte{}
+)
+
+func rememberSaved(path string, b []byte) {
+	savedMu.Lock()
+	savedSum[path] = sha256.Sum256(b)
+	savedMu.Unlock()
+}
+
+func isOwnWrite(path string, b []byte) bool {
+	savedMu.Lock()
+	defer savedMu.Unlock()
+	sum, ok := savedSum[path]
+	return ok && sum == sha256.Sum256(b)
+}
+
+type fileStamp struct {
+	mod  time.Time
+	size int64
+}
+
+func stampOf(path string) fileStamp {
+	fi, err := os.Stat(path)
+	if err != nil {
+		return fileStamp{}
+	}
+	return fileStamp{fi.ModTime(), fi.Size()}
+}
+
+// Watch pollt ConfigPath(appDir) und ruft onChange mit der neu geladenen Config auf,
+// wenn die Datei von außen geändert wurde. Blockiert, bis ctx endet.
+func Watch(ctx context.Context, appDir string, every time.Duration, onChange func(Config)) {
+	path := ConfigPath(appDir)
+	last := stampOf(path)
+
+	t := time.NewTicker(every)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		st := stampOf(path)
+		if st == last {
+			continue
+		}
+		last = st
+
+		b, err := os.ReadFile(path)
+		if err != nil || isOwnWrite(path, b) {
+			continue
+		}
+		c, err := Load(appDir)
+		if err != nil {
+			// halb geschriebene/kaputte Datei: nichts anwenden, auf die nächste Änderung warten
+			slog.Warn("reload config", "err", err)
+			continue
+		}
+		onChange(c)
+	}
+}
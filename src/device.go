@@ -0,0 +1,161 @@
+package main
+
+import (
+	"io"
+	"log/slog"
+	"path/filepath"
+	"time"
+
+	"hp90epc/alarm"
+	"hp90epc/config"
+	"hp90epc/logging"
+	"hp90epc/model"
+	"hp90epc/reader"
+)
+
+// device: ein Messgerät mit eigenem Reader, Live-Puffer, Verlauf und Logger.
+type device struct {
+	latest  *model.LatestBuffer
+	history *model.History
+	byMode  *model.ByMode
+	counts  *model.ModeCounts
+	events  *model.EventLog
+	rel     *model.Rel
+	freeze  *model.Freeze
+	alarms  *alarm.Monitor
+	mgr     *reader.Manager
+	logger  *logging.Logger
+}
+
+func newDevice(cfg config.Config, id, logDir string, proto reader.Protocol) *device {
+	latest := &model.LatestBuffer{}
+	history := model.NewHistory(cfg.HistorySize)
+	latest.Subscribe(history.Add)
+	byMode := &model.ByMode{}
+	latest.Subscribe(byMode.Add)
+	counts := &model.ModeCounts{}
+	latest.Subscribe(counts.Add)
+	events := model.NewEventLog(cfg.EventLogSize)
+	latest.Subscribe(events.Add)
+	rel := &model.Rel{}
+	latest.Subscribe(rel.Add)
+	alarms := alarm.NewMonitor(id)
+	configureAlarms(alarms, cfg)
+	latest.Subscribe(alarms.Check)
+	logger := logging.NewLogger(logDir, time.Duration(cfg.LogIntervalMs)*time.Millisecond)
+	configureLogger(logger, cfg)
+	configureEvents(events, logger, cfg)
+	mgr := reader.NewManager(latest, logger, time.Duration(cfg.StaleAfterMs)*time.Millisecond)
+	mgr.SetProtocol(proto)
+	mgr.SetMaxFrameHz(cfg.MaxFrameHz)
+	mgr.SetUnitStyle(unitStyle(cfg))
+	mgr.SetReadBufSize(cfg.ReadBufferSize)
+	mgr.SetWatchdog(time.Duration(cfg.WatchdogTimeoutMs) * time.Millisecond)
+	return &device{latest: latest, history: history, byMode: byMode, counts: counts, events: events, rel: rel, freeze: &model.Freeze{}, alarms: alarms, mgr: mgr, logger: logger}
+}
+
+// configureLogger überträgt die Logging-Einstellungen (ohne Verzeichnis) auf l.
+func configureLogger(l *logging.Logger, cfg config.Config) {
+	l.SetInterval(cfg.LogIntervalMs)
+	l.SetFormat(cfg.LogFormat)
+	l.SetNamePattern(cfg.LogNamePattern)
+	l.SetTimeFormat(cfg.LogTimeFormat)
+	l.SetCSVDelimiter(firstRune(cfg.CSVDelimiter))
+	l.SetDecimalComma(cfg.CSVDecimalComma)
+	l.SetFloatFormat(cfg.LogFloatFormat, cfg.LogFloatPrecision)
+	l.SetDisplayColumn(cfg.CSVDisplayColumn)
+	l.SetIncludeRaw(cfg.RawIncluded())
+	l.SetCSVComment(cfg.CSVComment)
+	l.SetCompressClosed(cfg.LogCompressClosed)
+	l.SetFlushEvery(cfg.LogFlushRows, time.Duration(cfg.LogFlushMs)*time.Millisecond)
+	l.SetMaxFileBytes(cfg.LogMaxFileBytes)
+	l.SetRotateEvery(time.Duration(cfg.LogRotateMinutes) * time.Minute)
+	l.SetAggregate(time.Duration(cfg.LogAggregateSec) * time.Second)
+}
+
+// unitStyle: Schreibweise der Einheiten aus der Config.
+func unitStyle(cfg config.Config) model.UnitStyle {
+	return model.UnitStyle{Ohm: cfg.UnitOhm, ASCII: cfg.UnitASCII}
+}
+
+// configureEvents: Funktionswechsel bei event_log_file zusätzlich in events_<datum>.jsonl mitschreiben.
+func configureEvents(e *model.EventLog, l *logging.Logger, cfg config.Config) {
+	if !cfg.EventLogFile {
+		e.OnEvent(nil)
+		return
+	}
+	e.OnEvent(func(ev model.ModeEvent) {
+		if err := l.AppendEvent(ev); err != nil {
+			slog.Warn("write mode event", "err", err)
+		}
+	})
+}
+
+// configureAlarms überträgt Regeln, Webhook und Debounce auf m.
+func configureAlarms(m *alarm.Monitor, cfg config.Config) {
+	m.SetRules(cfg.Alarms, cfg.AlarmWebhook, time.Duration(cfg.AlarmDebounceMs)*time.Millisecond)
+}
+
+// extraLogDir: zusätzliche Geräte loggen in ein Unterverzeichnis je Geräte-ID.
+func extraLogDir(base, id string) string {
+	return filepath.Join(base, id)
+}
+
+// GetLatest: bei eingefrorener Anzeige der eingefrorene Wert, sonst der aktuelle.
+func (d *device) GetLatest() *model.Measurement {
+	if m := d.freeze.Measurement(); m != nil {
+		return m
+	}
+	return d.latest.Get()
+}
+func (d *device) GetLatestSeq() (*model.Measurement, uint64) {
+	if m, seq := d.freeze.MeasurementSeq(); m != nil {
+		return m, seq
+	}
+	return d.latest.GetSeq()
+}
+func (d *device) SubscribeLive(fn func(*model.Measurement)) (cancel func()) {
+	return d.latest.Subscribe(fn)
+}
+func (d *device) GetHistory(n int) []model.Sample          { return d.history.Last(n) }
+func (d *device) GetByMode() map[string]*model.Measurement { return d.byMode.All() }
+func (d *device) GetAlarmStatus() []alarm.Status           { return d.alarms.Status() }
+func (d *device) GetReaderStatus() reader.Status           { return d.mgr.GetStatus() }
+func (d *device) GetModeStats() model.ModeStats            { return d.counts.Get() }
+func (d *device) GetEvents(n int) []model.ModeEvent        { return d.events.Last(n) }
+func (d *device) ResetEvents()                             { d.events.Reset() }
+func (d *device) SetRel() (model.RelState, error)          { return d.rel.Set(d.latest.Get()) }
+func (d *device) ClearRel()                                { d.rel.Clear() }
+func (d *device) GetRel() model.RelState                   { return d.rel.Get() }
+func (d *device) Freeze() (model.FreezeState, error)       { return d.freeze.Set(d.latest.GetSeq()) }
+func (d *device) Unfreeze()                                { d.freeze.Clear() }
+func (d *device) GetFreeze() model.FreezeState             { return d.freeze.Get() }
+
+// Log-Dateien im Verzeichnis dieses Geräts (server.LogFiles); Start/Stop steuert app für alle Geräte.
+func (d *device) GetLogStatus() logging.LogStatus                { return d.logger.Status() }
+func (d *device) LogListFiles() ([]string, error)                { return d.logger.ListFiles() }
+func (d *device) LogReadFile(name string) ([]byte, error)        { return d.logger.ReadFile(name) }
+func (d *device) LogOpenFile(name string) (io.ReadCloser, error) { return d.logger.OpenFile(name) }
+func (d *device) LogDeleteFile(name string) error                { return d.logger.DeleteFile(name) }
+func (d *device) LogTail(name string, n int) ([]string, error)   { return d.logger.Tail(name, n) }
+func (d *device) LogReadFileRepaired(name string) ([]byte, logging.Validation, error) {
+	return d.logger.ReadFileRepaired(name)
+}
+func (d *device) LogValidateFile(name string) (logging.Validation, error) {
+	return d.logger.Validate(name)
+}
+func (d *device) LogOpenRange(name string, from, to time.Time) (*logging.CSVRange, error) {
+	return d.logger.OpenRange(name, from, to)
+}
+func (d *device) LogListFilesWithNotes() ([]logging.FileNote, error) {
+	return d.logger.ListFilesWithNotes()
+}
+func (d *device) LogListFilesDetailed() ([]logging.FileInfo, error) {
+	return d.logger.ListFilesDetailed()
+}
+
+// ResetReaderStats nullt Reader-Zähler und Modus-Statistik gemeinsam.
+func (d *device) ResetReaderStats() {
+	d.mgr.ResetStats()
+	d.counts.Reset()
+}
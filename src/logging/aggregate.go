@@ -0,0 +1,129 @@
+package logging
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+	"strings"
+	"time"
+
+	"hp90epc/model"
+)
+
+// Kopfzeile im Aggregat-Modus: eine Zeile pro Fenster statt pro Sample.
+var aggHeader = []string{
+	"window_start", "window_end",
+	"samples", "count",
+	"min", "max", "mean",
+	"unit", "mode",
+}
+
+// aggRecord: eine Fenster-Zeile im JSONL-Log; min/max/mean null ohne Zahlenwerte.
+type aggRecord struct {
+	WindowStart string   `json:"window_start"`
+	WindowEnd   string   `json:"window_end"`
+	Samples     int      `json:"samples"`
+	Count       int      `json:"count"`
+	Min         *float64 `json:"min"`
+	Max         *float64 `json:"max"`
+	Mean        *float64 `json:"mean"`
+	Unit        string   `json:"unit"`
+	Mode        string   `json:"mode"`
+}
+
+// SetAggregate schaltet auf eine Zeile pro Fenster (min/max/mean/count) um; 0 = Rohdaten.
+// Greift ab der nächsten Datei.
+func (l *Logger) SetAggregate(window time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if window < 0 {
+		window = 0
+	}
+	l.aggWindow = window
+}
+
+// aggregate sammelt m im laufenden Fenster. Beim Fensterwechsel wird die fertige Zeile
+// noch in die bisherige Datei geschrieben und erst danach ggf. rotiert.
+func (l *Logger) aggregate(m *model.Measurement) {
+	t := m.Timestamp
+	if t.IsZero() {
+		t = time.Now()
+	}
+	start := periodStart(t, l.fileAgg)
+
+	if l.stats.Samples > 0 && !start.Equal(l.aggStart) {
+		err := l.writeAggregate()
+		if err == nil && l.needsRotate(t) {
+			err = l.rotate()
+		}
+		if err != nil {
+			l.fail("logger write", err)
+			return
+		}
+		if l.fileAgg == 0 {
+			// Modus wurde für die neue Datei abgeschaltet
+			l.writeSample(m)
+			return
+		}
+		start = periodStart(t, l.fileAgg)
+	}
+	if l.stats.Samples == 0 {
+		l.aggStart = start
+	}
+	l.stats.Add(m)
+}
+
+// writeAggregate schreibt das laufende Fenster (auch ein angebrochenes) und setzt es zurück.
+func (l *Logger) writeAggregate() error {
+	s := &l.stats
+	if s.Samples == 0 {
+		return nil
+	}
+	defer s.Reset()
+
+	start := l.aggStart.Format(l.timeFormat)
+	end := l.aggStart.Add(l.fileAgg).Format(l.timeFormat)
+	if l.jsonl != nil {
+		rec := aggRecord{
+			WindowStart: start, WindowEnd: end,
+			Samples: s.Samples, Count: s.Count,
+			Unit: s.Unit(), Mode: s.Mode(),
+		}
+		if s.Count > 0 {
+			mn, mx, mean := s.Min, s.Max, s.Mean()
+			rec.Min, rec.Max, rec.Mean = &mn, &mx, &mean
+		}
+		if err := l.jsonl.Encode(rec); err != nil {
+			return err
+		}
+		return l.rowWritten()
+	}
+
+	num := func(v float64) string {
+		if s.Count == 0 || math.IsNaN(v) {
+			return ""
+		}
+		return l.formatFloat(v)
+	}
+	record := []string{
+		start, end,
+		strconv.Itoa(s.Samples), strconv.Itoa(s.Count),
+		num(s.Min), num(s.Max), num(s.Mean()),
+		s.Unit(), s.Mode(),
+	}
+	if err := l.csv.Write(record); err != nil {
+		return err
+	}
+	return l.rowWritten()
+}
+
+func (l *Logger) formatFloat(v float64) string {
+	s := fmt.Sprintf("%g", v)
+	if l.floatFmt == 'f' {
+		s = strconv.FormatFloat(v, 'f', l.floatPrec, 64)
+	}
+	if l.decimalComma {
+		s = strings.Replace(s, ".", ",", 1)
+	}
+	return s
+}
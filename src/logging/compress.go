@@ -0,0 +1,112 @@
+package logging
+
+import (
+	"bufio"
+	"compress/gzip"
+	"io"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+)
+
+// GzipSuffix: abgeschlossene Log-Dateien mit SetCompressClosed heißen "<datei>.csv.gz".
+const GzipSuffix = ".gz"
+
+// tmpSuffix: Zwischendatei beim Komprimieren; taucht in ListFiles nicht auf.
+const tmpSuffix = ".tmp"
+
+// SetCompressClosed komprimiert jede abgeschlossene Datei (Stop, Rotation) im Hintergrund
+// zu "<datei>.gz" und löscht das Original. Die aktive Datei bleibt immer unkomprimiert.
+func (l *Logger) SetCompressClosed(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.compress = on
+}
+
+// Wait wartet auf laufende Komprimierungen, z.B. vor dem Programmende.
+func (l *Logger) Wait() {
+	l.compressing.Wait()
+}
+
+// compressLater startet die Komprimierung von name, falls eingeschaltet. Aufruf unter l.mu.
+func (l *Logger) compressLater(name string) {
+	if !l.compress || name == "" {
+		return
+	}
+	full := filepath.Join(l.dir, name)
+	l.compressing.Add(1)
+	go func() {
+		defer l.compressing.Done()
+		if err := compressFile(full); err != nil {
+			slog.Warn("compress log file", "file", name, "err", err)
+		}
+	}()
+}
+
+// compressFile schreibt full.gz über eine Zwischendatei und entfernt erst danach das Original;
+// die Sidecar-Datei wandert mit. Ein vorhandenes full.gz wird nie ersetzt, full bleibt dann unkomprimiert.
+func compressFile(full string) error {
+	if _, err := os.Lstat(full + GzipSuffix); err == nil {
+		return &os.PathError{Op: "compress", Path: full + GzipSuffix, Err: os.ErrExist}
+	}
+	in, err := os.Open(full)
+	if err != nil {
+		return err
+	}
+	defer in.Close()
+
+	tmp := full + GzipSuffix + tmpSuffix
+	out, err := os.OpenFile(tmp, os.O_WRONLY|os.O_CREATE|os.O_TRUNC, 0o644)
+	if err != nil {
+		return err
+	}
+	zw := gzip.NewWriter(out)
+	zw.Name = filepath.Base(full)
+	_, err = io.Copy(zw, in)
+	if cerr := zw.Close(); err == nil {
+		err = cerr
+	}
+	if cerr := out.Close(); err == nil {
+		err = cerr
+	}
+	if err == nil {
+		err = os.Rename(tmp, full+GzipSuffix)
+	}
+	if err != nil {
+		_ = os.Remove(tmp)
+		return err
+	}
+	if err := os.Rename(full+MetaSuffix, full+GzipSuffix+MetaSuffix); err != nil && !os.IsNotExist(err) {
+		slog.Warn("move session meta", "file", full, "err", err)
+	}
+	in.Close()
+	return os.Remove(full)
+}
+
+// openLog öffnet eine Log-Datei zum Lesen; ".gz" wird transparent entpackt.
+func openLog(full string) (io.ReadCloser, error) {
+	f, err := os.Open(full)
+	if err != nil {
+		return nil, err
+	}
+	if !strings.HasSuffix(full, GzipSuffix) {
+		return f, nil
+	}
+	zr, err := gzip.NewReader(bufio.NewReader(f))
+	if err != nil {
+		f.Close()
+		return nil, err
+	}
+	return gzipFile{zr, f}, nil
+}
+
+type gzipFile struct {
+	*gzip.Reader
+	f *os.File
+}
+
+func (g gzipFile) Close() error {
+	g.Reader.Close()
+	return g.f.Close()
+}
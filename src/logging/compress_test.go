@@ -0,0 +1,77 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"slices"
+	"strings"
+	"testing"
+)
+
+// TestCompressKeepsEarlierArchive: mit festem Namensmuster darf die zweite Session das
+// komprimierte Log der ersten nicht überschreiben.
+func TestCompressKeepsEarlierArchive(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(dir, 0)
+	l.SetNamePattern("fixed")
+	l.SetCompressClosed(true)
+
+	for i, note := range []string{"erste", "zweite"} {
+		if err := l.SetNote(note); err != nil {
+			t.Fatal(err)
+		}
+		if err := l.Start(); err != nil {
+			t.Fatal(err)
+		}
+		l.Push(testMeasurement(float64(i)))
+		if err := l.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		l.Wait()
+	}
+
+	files, err := l.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	slices.Sort(files)
+	if want := []string{"fixed.csv.gz", "fixed_2.csv.gz"}; !reflect.DeepEqual(files, want) {
+		t.Fatalf("files = %q, want %q", files, want)
+	}
+	notes := map[string]string{}
+	for _, name := range files {
+		data, err := l.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if n := strings.Count(strings.TrimSpace(string(data)), "\n"); n != 1 {
+			t.Errorf("%s: %d data rows, want 1", name, n)
+		}
+		notes[name] = readNote(filepath.Join(dir, name+MetaSuffix))
+	}
+	if notes["fixed.csv.gz"] != "erste" || notes["fixed_2.csv.gz"] != "zweite" {
+		t.Errorf("notes = %v, sidecar of the first session was overwritten", notes)
+	}
+}
+
+func TestCompressFileRefusesOverwrite(t *testing.T) {
+	dir := t.TempDir()
+	full := filepath.Join(dir, "x.csv")
+	if err := os.WriteFile(full, []byte("neu\n"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(full+GzipSuffix, []byte("altes archiv"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	if err := compressFile(full); !errors.Is(err, os.ErrExist) {
+		t.Fatalf("compressFile err = %v, want ErrExist", err)
+	}
+	if b, _ := os.ReadFile(full + GzipSuffix); string(b) != "altes archiv" {
+		t.Errorf("existing archive changed to %q", b)
+	}
+	if _, err := os.Stat(full); err != nil {
+		t.Errorf("original removed although not compressed: %v", err)
+	}
+}
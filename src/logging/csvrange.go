@@ -0,0 +1,126 @@
+package logging
+
+import (
+	"bufio"
+	"encoding/csv"
+	"errors"
+	"io"
+	"slices"
+	"time"
+)
+
+// ErrNoTimestamp: Zeitfilter nur für CSV-Dateien mit timestamp- bzw. window_start-Spalte
+// (vor Schema v2 geschriebene Dateien haben keine).
+var ErrNoTimestamp = errors.New("log file has no timestamp column")
+
+// CSVRange: geöffnete CSV-Log-Datei mit gelesener Kopfzeile; Copy streamt nur die Zeilen
+// im Zeitfenster, ohne die Datei komplett zu laden.
+type CSVRange struct {
+	rc      io.ReadCloser
+	r       *csv.Reader
+	comma   rune
+	header  []string
+	tsCol   int
+	layouts []string
+	from    time.Time
+	to      time.Time
+}
+
+// OpenRange öffnet name für Copy. from (inklusive) und to (exklusive) dürfen Nullzeit sein = offen.
+// Fehler (ErrNotCSV, ErrNoTimestamp, fehlende Datei) kommen hier, bevor etwas geschrieben wurde.
+func (l *Logger) OpenRange(name string, from, to time.Time) (*CSVRange, error) {
+	if err := csvName(name); err != nil {
+		return nil, err
+	}
+	full, err := l.resolveLogPath(name)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := openLog(full)
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	comma, layout := l.comma, l.timeFormat
+	l.mu.Unlock()
+
+	br := bufio.NewReaderSize(rc, 64*1024)
+	head, _ := br.Peek(64 * 1024)
+	c := &CSVRange{
+		rc:    rc,
+		r:     csv.NewReader(br),
+		comma: sniffComma(head, comma),
+		// Dateien mit älterem log_time_format: zumindest den Default lesen
+		layouts: []string{layout, time.RFC3339Nano},
+		from:    from,
+		to:      to,
+	}
+	c.r.Comma = c.comma
+	c.r.Comment = '#'
+	c.r.FieldsPerRecord = -1
+	c.header, err = c.r.Read()
+	if err != nil {
+		rc.Close()
+		if err == io.EOF {
+			return nil, ErrNoTimestamp
+		}
+		return nil, err
+	}
+	c.tsCol = slices.Index(c.header, "timestamp")
+	if c.tsCol < 0 {
+		c.tsCol = slices.Index(c.header, "window_start")
+	}
+	if c.tsCol < 0 {
+		rc.Close()
+		return nil, ErrNoTimestamp
+	}
+	return c, nil
+}
+
+// Copy schreibt die Kopfzeile und alle Zeilen im Zeitfenster als CSV nach w und liefert deren Anzahl.
+// Zeilen mit falscher Spaltenzahl (z.B. abgeschnittene letzte Zeile) oder unlesbarem Zeitstempel
+// entfallen.
+func (c *CSVRange) Copy(w io.Writer) (int, error) {
+	cw := csv.NewWriter(w)
+	cw.Comma = c.comma
+	if err := cw.Write(c.header); err != nil {
+		return 0, err
+	}
+	rows := 0
+	for {
+		rec, err := c.r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			cw.Flush()
+			return rows, err
+		}
+		if len(rec) != len(c.header) {
+			continue
+		}
+		t, ok := c.parseTime(rec[c.tsCol])
+		if !ok || (!c.from.IsZero() && t.Before(c.from)) || (!c.to.IsZero() && !t.Before(c.to)) {
+			continue
+		}
+		if err := cw.Write(rec); err != nil {
+			return rows, err
+		}
+		rows++
+	}
+	cw.Flush()
+	return rows, cw.Error()
+}
+
+func (c *CSVRange) parseTime(s string) (time.Time, bool) {
+	for _, layout := range c.layouts {
+		if t, err := time.ParseInLocation(layout, s, time.Local); err == nil {
+			return t, true
+		}
+	}
+	return time.Time{}, false
+}
+
+func (c *CSVRange) Close() error {
+	return c.rc.Close()
+}
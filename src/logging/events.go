@@ -0,0 +1,34 @@
+package logging
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"path/filepath"
+
+	"hp90epc/model"
+)
+
+// AppendEvent hängt einen Funktionswechsel an die Tagesdatei events_<datum>.jsonl an,
+// unabhängig davon, ob das Intervall-Logging läuft (wie Snapshot).
+func (l *Logger) AppendEvent(ev model.ModeEvent) error {
+	b, err := json.Marshal(ev)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return fmt.Errorf("mkdir logs: %w", err)
+	}
+	name := "events_" + ev.Time.Format("2006-01-02") + "." + FormatJSONL
+	f, err := os.OpenFile(filepath.Join(l.dir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open event file: %w", err)
+	}
+	if _, err := f.Write(append(b, '\n')); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write event: %w", err)
+	}
+	return f.Close()
+}
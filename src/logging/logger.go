@@ -3,40 +3,137 @@ package logging
 import (
 	"bufio"
 	"encoding/csv"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
+	"log/slog"
 	"os"
 	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
 	"time"
+	"unicode/utf8"
 
+	"hp90epc/buildinfo"
 	"hp90epc/model"
 )
 
+// DefaultTimeFormat: RFC3339 mit Millisekunden für die timestamp-Spalte.
+const DefaultTimeFormat = "2006-01-02T15:04:05.000Z07:00"
+
+// Log-Formate
+const (
+	FormatCSV   = "csv"
+	FormatJSONL = "jsonl"
+)
+
+var (
+	ErrInvalidName = errors.New("invalid log file name")
+	ErrFileActive  = errors.New("log file is currently being written")
+	ErrNotActive   = errors.New("logging is not active")
+	// ErrLoggingActive: Änderung nur bei gestopptem Logging möglich
+	ErrLoggingActive = errors.New("stop logging first")
+)
+
 type LogStatus struct {
-	Active     bool   `json:"active"`
-	File       string `json:"file"`
+	Active bool `json:"active"`
+	// Paused: Datei bleibt offen, Push verwirft Samples bis Resume
+	Paused bool   `json:"paused"`
+	File   string `json:"file"`
+	// Dir: effektives, absolutes Log-Verzeichnis
+	Dir        string `json:"dir"`
 	IntervalMs int    `json:"interval_ms"`
+	// Note: Notiz der laufenden bzw. nächsten Session, steht in <datei>.meta.json
+	Note string `json:"note"`
+	// LastError: warum das Logging zuletzt von selbst gestoppt hat (z.B. Platte voll); leer nach Start
+	LastError string `json:"last_error"`
 }
 
 type Logger struct {
-	active bool
+	// mu schützt alle Felder: Push läuft im Reader-Goroutine, alles andere kommt aus HTTP-Handlern
+	mu sync.Mutex
+
+	active  bool
+	paused  bool
+	lastErr string
+
+	// Session-Daten für die Sidecar-Datei
+	port string
+	baud int
+	note string
 
 	dir       string
 	interval  time.Duration
 	lastWrite time.Time
 
 	file        *os.File
+	buf         *bufio.Writer
+	out         *countingWriter
 	csv         *csv.Writer
+	jsonl       *json.Encoder
 	currentName string
+
+	format       string
+	namePattern  string
+	timeFormat   string
+	comma        rune
+	decimalComma bool
+	// Zahlenformat der value-Spalte: 'g' (%g, kürzeste Darstellung) oder 'f' mit floatPrec Nachkommastellen
+	floatFmt  byte
+	floatPrec int
+	// zusätzliche CSV-Spalte "display" ("12.34 mV DC"); fileDisplay gilt für die offene Datei
+	display     bool
+	fileDisplay bool
+	// Spalte bzw. Feld "raw" (Rohframe als Hex); fileRaw gilt für die offene Datei
+	raw     bool
+	fileRaw bool
+	// Kommentarzeile "# ..." mit Version, Gerät, Start und Intervall vor der CSV-Kopfzeile
+	comment bool
+	// abgeschlossene Dateien im Hintergrund zu .gz komprimieren
+	compress    bool
+	compressing sync.WaitGroup
+
+	// Flush-Strategie: nach flushRows Zeilen und/oder spätestens flushEvery nach der ersten ungeschriebenen
+	flushRows  int
+	flushEvery time.Duration
+	pending    int
+	flushTimer *time.Timer
+
+	maxBytes    int64
+	rotateEvery time.Duration
+	openedAt    time.Time
+
+	// Aggregat-Modus: aggWindow ist konfiguriert, fileAgg gilt für die offene Datei
+	aggWindow time.Duration
+	fileAgg   time.Duration
+	aggStart  time.Time
+	stats     model.Stats
+
+	// breites Format: eine Zeile je Zeitpunkt mit Spalten je Quelle (PushAll); wide ist konfiguriert,
+	// fileWide gilt für die offene Datei, leer = langes Format (Push)
+	wide     []string
+	fileWide []string
 }
 
 func NewLogger(dir string, interval time.Duration) *Logger {
 	return &Logger{
-		dir:      dir,
-		interval: interval,
+		dir:         dir,
+		interval:    interval,
+		flushRows:   1,
+		format:      FormatCSV,
+		namePattern: DefaultNamePattern,
+		comma:       ',',
+		floatFmt:    'g',
+		raw:         true,
+		timeFormat:  DefaultTimeFormat,
 	}
 }
 
 func (l *Logger) Start() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if l.active {
 		return nil
 	}
@@ -44,88 +141,556 @@ func (l *Logger) Start() error {
 	if err := os.MkdirAll(l.dir, 0o755); err != nil {
 		return fmt.Errorf("mkdir logs: %w", err)
 	}
+	if err := l.openFile(); err != nil {
+		return err
+	}
 
-	ts := time.Now().Format("2006-01-02_15-04-05")
-	name := fmt.Sprintf("hp90epc_%s.csv", ts)
-	full := filepath.Join(l.dir, name)
+	l.lastWrite = time.Time{}
+	l.active = true
+	l.lastErr = ""
+	return nil
+}
 
-	f, err := os.Create(full)
-	if err != nil {
-		return fmt.Errorf("create log file: %w", err)
+// fail beendet das Logging nach einem Schreibfehler; der Fehler bleibt in Status().LastError sichtbar.
+func (l *Logger) fail(op string, err error) {
+	slog.Error(op, "err", err)
+	l.active = false
+	l.paused = false
+	l.lastErr = err.Error()
+	_ = l.closeFile()
+}
+
+// openFile legt eine neue, zeitgestempelte Log-Datei samt Header an.
+// Existiert der Name schon (Rotation in derselben Sekunde, festes Namensmuster), auch als
+// komprimierte "<name>.gz", wird ein Zähler angehängt.
+func (l *Logger) openFile() error {
+	ext := "." + l.format
+	base := l.expandName(time.Now())
+	name := base + ext
+
+	var f *os.File
+	for i := 2; ; i++ {
+		var err error
+		f, err = createLog(filepath.Join(l.dir, name))
+		if err == nil {
+			break
+		}
+		if !os.IsExist(err) || i > 1000 {
+			return fmt.Errorf("create log file: %w", err)
+		}
+		name = fmt.Sprintf("%s_%d%s", base, i, ext)
 	}
 
-	w := csv.NewWriter(f)
-	header := []string{
-		"value", "value_str", "unit", "mode",
-		"auto", "hold", "rel", "low_batt",
-		"raw",
+	buf := bufio.NewWriter(f)
+	out := &countingWriter{w: buf}
+	l.csv = nil
+	l.jsonl = nil
+	if l.format == FormatJSONL {
+		// JSONL ist selbstbeschreibend, kein Header
+		l.jsonl = json.NewEncoder(out)
+	} else {
+		w := csv.NewWriter(out)
+		w.Comma = l.comma
+		header := l.header()
+		if l.comment {
+			if _, err := io.WriteString(out, l.commentLine(time.Now())); err != nil {
+				_ = f.Close()
+				return fmt.Errorf("write header: %w", err)
+			}
+		}
+		if err := w.Write(header); err != nil {
+			_ = f.Close()
+			return fmt.Errorf("write header: %w", err)
+		}
+		w.Flush()
+		l.csv = w
 	}
-	if err := w.Write(header); err != nil {
+	if err := buf.Flush(); err != nil {
 		_ = f.Close()
 		return fmt.Errorf("write header: %w", err)
 	}
-	w.Flush()
 
 	l.file = f
-	l.csv = w
+	l.buf = buf
+	l.out = out
 	l.currentName = name
-	l.lastWrite = time.Time{}
-	l.active = true
+	l.openedAt = time.Now()
+	l.fileSettings()
+	if err := l.writeMeta(); err != nil {
+		// Sidecar ist Beiwerk, das Log selbst läuft weiter
+		slog.Warn("write session meta", "file", name, "err", err)
+	}
 	return nil
 }
 
+// createLog legt full exklusiv an. Ein vorhandenes full.gz zählt als belegt: sonst würde das
+// Komprimieren der neuen Datei das alte Archiv samt Sidecar ersetzen.
+func createLog(full string) (*os.File, error) {
+	if _, err := os.Lstat(full + GzipSuffix); err == nil {
+		return nil, &os.PathError{Op: "create", Path: full + GzipSuffix, Err: os.ErrExist}
+	}
+	return os.OpenFile(full, os.O_WRONLY|os.O_CREATE|os.O_EXCL, 0o644)
+}
+
+// fileSettings übernimmt die Einstellungen, die für eine ganze Datei gelten, beim Öffnen.
+func (l *Logger) fileSettings() {
+	l.fileWide = slices.Clone(l.wide)
+	l.fileAgg = l.aggWindow
+	if len(l.fileWide) > 0 {
+		l.fileAgg = 0
+	}
+	l.fileDisplay = l.display && l.fileAgg == 0 && len(l.fileWide) == 0 && l.format != FormatJSONL
+	l.fileRaw = l.raw
+	l.stats.Reset()
+}
+
+// header: CSV-Kopfzeile für die nächste Datei (breit, Aggregat-Modus bzw. ohne raw/mit display-Spalte)
+func (l *Logger) header() []string {
+	if len(l.wide) > 0 {
+		return wideHeader(l.wide)
+	}
+	if l.aggWindow > 0 {
+		return aggHeader
+	}
+	h := csvHeader
+	if !l.raw {
+		h = csvHeader[:len(csvHeader)-1]
+	}
+	if l.display {
+		return append(h[:len(h):len(h)], "display")
+	}
+	return h
+}
+
+// CSV-Schema v4: overload vor raw
+// (v3: diode/continuity/beep, v2: führende timestamp-Spalte, v1 begann direkt mit value)
+var csvHeader = []string{
+	"timestamp",
+	"value", "value_str", "unit", "mode",
+	"auto", "hold", "rel", "low_batt",
+	"diode", "continuity", "beep",
+	"overload",
+	"raw",
+}
+
+func (l *Logger) closeFile() error {
+	err := l.flush()
+	if l.file != nil {
+		if cerr := l.file.Close(); err == nil {
+			err = cerr
+		}
+	}
+	l.csv = nil
+	l.buf = nil
+	l.jsonl = nil
+	l.out = nil
+	l.file = nil
+	return err
+}
+
+// rotate schließt die aktuelle Datei vollständig, bevor die neue übernommen wird,
+// damit ein Tail auf die alte Datei nie eine halbe Zeile sieht.
+func (l *Logger) rotate() error {
+	name := l.currentName
+	if err := l.closeFile(); err != nil {
+		return err
+	}
+	l.compressLater(name)
+	return l.openFile()
+}
+
 func (l *Logger) Stop() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if !l.active {
 		return nil
 	}
 	l.active = false
+	l.paused = false
+	l.note = ""
+	if l.fileAgg > 0 {
+		// angebrochenes Fenster nicht verlieren
+		if err := l.writeAggregate(); err != nil {
+			slog.Error("logger write", "err", err)
+		}
+	}
+	if err := l.closeFile(); err != nil {
+		return err
+	}
+	l.compressLater(l.currentName)
+	return nil
+}
 
-	if l.csv != nil {
-		l.csv.Flush()
+// Pause verwirft Samples, lässt aber Datei und Header offen; Resume schreibt in dieselbe Datei weiter.
+func (l *Logger) Pause() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.active {
+		return ErrNotActive
 	}
-	if l.file != nil {
-		if err := l.file.Close(); err != nil {
-			return err
-		}
+	if !l.paused {
+		_ = l.flush()
 	}
+	l.paused = true
+	return nil
+}
 
-	l.csv = nil
-	l.file = nil
+func (l *Logger) Resume() error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.active {
+		return ErrNotActive
+	}
+	if l.paused {
+		// erstes Sample nach dem Resume sofort schreiben
+		l.lastWrite = time.Time{}
+	}
+	l.paused = false
 	return nil
 }
 
 func (l *Logger) Status() LogStatus {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	return LogStatus{
 		Active:     l.active,
+		Paused:     l.paused,
 		File:       l.currentName,
+		Dir:        l.dir,
 		IntervalMs: int(l.interval / time.Millisecond),
+		Note:       l.note,
+		LastError:  l.lastErr,
+	}
+}
+
+// SetDir wechselt das Log-Verzeichnis und legt es an; bei laufendem Logging ErrLoggingActive.
+func (l *Logger) SetDir(dir string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active {
+		return ErrLoggingActive
+	}
+	if dir == "" {
+		return errors.New("log dir required")
+	}
+	if err := os.MkdirAll(dir, 0o755); err != nil {
+		return fmt.Errorf("mkdir logs: %w", err)
 	}
+	l.dir = dir
+	l.currentName = ""
+	return nil
 }
 
+// SetInterval setzt den Mindestabstand zwischen zwei Zeilen; 0 = jeder Frame, negativ = 1000ms.
 func (l *Logger) SetInterval(ms int) {
-	if ms <= 0 {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if ms < 0 {
 		ms = 1000
 	}
 	l.interval = time.Duration(ms) * time.Millisecond
 }
 
+// SetNamePattern setzt die Vorlage für Dateinamen (ohne Endung), siehe DefaultNamePattern;
+// leer = Default. Greift ab der nächsten Datei.
+func (l *Logger) SetNamePattern(p string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if strings.TrimSpace(p) == "" {
+		p = DefaultNamePattern
+	}
+	l.namePattern = p
+}
+
+// SetFormat wählt FormatCSV oder FormatJSONL; greift ab der nächsten Datei.
+func (l *Logger) SetFormat(format string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if format != FormatJSONL {
+		format = FormatCSV
+	}
+	l.format = format
+}
+
+// SetCSVDelimiter setzt das Feldtrennzeichen (Default ','); greift ab der nächsten Datei.
+// Ungültige Trennzeichen (Anführungszeichen, Zeilenumbruch) werden ignoriert.
+func (l *Logger) SetCSVDelimiter(r rune) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if r == 0 || r == '"' || r == '\r' || r == '\n' || r == utf8.RuneError {
+		r = ','
+	}
+	l.comma = r
+}
+
+// SetDecimalComma: Zahlen mit ',' statt '.' schreiben. Zusammen mit ';' als Trennzeichen
+// ergibt das CSVs, die ein deutsches Excel direkt korrekt öffnet.
+func (l *Logger) SetDecimalComma(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.decimalComma = on
+}
+
+// SetFloatFormat: "fixed" schreibt Werte mit prec Nachkommastellen (nie Exponentenschreibweise),
+// alles andere wie bisher mit %g. Gilt für CSV-Zeilen, Aggregate und das Wide-Layout.
+func (l *Logger) SetFloatFormat(format string, prec int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.floatFmt, l.floatPrec = 'g', 0
+	if format == "fixed" && prec >= 0 {
+		l.floatFmt, l.floatPrec = 'f', prec
+	}
+}
+
+// SetIncludeRaw: ohne raw fehlt die Spalte bzw. das JSONL-Feld "raw"; greift ab der nächsten Datei.
+// Snapshots behalten ihr festes Schema.
+func (l *Logger) SetIncludeRaw(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.raw = on
+}
+
+// SetDisplayColumn hängt an CSV-Zeilen eine lesbare Spalte "display" an (Wert, Einheit, Modus);
+// greift ab der nächsten Datei. Nicht im Aggregat-Modus und nicht für Snapshots.
+func (l *Logger) SetDisplayColumn(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.display = on
+}
+
+// SetCSVComment schreibt vor die CSV-Kopfzeile eine Kommentarzeile mit Herkunftsdaten;
+// greift ab der nächsten Datei. Strikte CSV-Parser kommen damit nicht klar, daher optional.
+func (l *Logger) SetCSVComment(on bool) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.comment = on
+}
+
+// commentLine: "# hp90epc <version>; port=…; baud=…; started=…; interval_ms=…[; note=…]"
+func (l *Logger) commentLine(started time.Time) string {
+	var b strings.Builder
+	b.WriteString("# hp90epc " + buildinfo.Version)
+	fmt.Fprintf(&b, "; port=%s; baud=%d", l.port, l.baud)
+	b.WriteString("; started=" + started.Format(time.RFC3339))
+	if len(l.wide) > 0 {
+		fmt.Fprintf(&b, "; interval_ms=%d; sources=%s", l.interval.Milliseconds(), strings.Join(l.wide, ","))
+	} else if l.aggWindow > 0 {
+		fmt.Fprintf(&b, "; aggregate_sec=%g", l.aggWindow.Seconds())
+	} else {
+		fmt.Fprintf(&b, "; interval_ms=%d", l.interval.Milliseconds())
+	}
+	if l.note != "" {
+		// Zeilenumbrüche würden die Kommentarzeile beenden
+		b.WriteString("; note=" + strings.Join(strings.Fields(l.note), " "))
+	}
+	b.WriteString("\n")
+	return b.String()
+}
+
+// SetTimeFormat setzt das Go-Zeitlayout der timestamp-Spalte; leer = DefaultTimeFormat.
+func (l *Logger) SetTimeFormat(layout string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if layout == "" {
+		layout = DefaultTimeFormat
+	}
+	l.timeFormat = layout
+}
+
+// SetFlushEvery bündelt Schreibzugriffe (schont SD-Karten): Flush nach rows Zeilen und/oder
+// spätestens every nach der ersten ungeschriebenen Zeile. 0 schaltet die jeweilige Grenze ab;
+// sind beide 0, wird jede Zeile sofort geschrieben (Default). Stop, Pause und Rotation flushen immer.
+func (l *Logger) SetFlushEvery(rows int, every time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if rows < 0 {
+		rows = 0
+	}
+	if every < 0 {
+		every = 0
+	}
+	if rows == 0 && every == 0 {
+		rows = 1
+	}
+	l.flushRows = rows
+	l.flushEvery = every
+}
+
+// SetMaxFileBytes aktiviert die Größen-Rotation; <= 0 schaltet sie ab.
+func (l *Logger) SetMaxFileBytes(n int64) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if n < 0 {
+		n = 0
+	}
+	l.maxBytes = n
+}
+
+// SetRotateEvery startet eine neue Datei an jeder Periodengrenze (z.B. stündlich, täglich); 0 = aus.
+func (l *Logger) SetRotateEvery(d time.Duration) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if d < 0 {
+		d = 0
+	}
+	l.rotateEvery = d
+}
+
+// needsRotate prüft Größen- und Zeitgrenze der aktuellen Datei.
+func (l *Logger) needsRotate(now time.Time) bool {
+	if l.maxBytes > 0 && l.out.n >= l.maxBytes {
+		return true
+	}
+	if l.rotateEvery > 0 && !periodStart(now, l.rotateEvery).Equal(periodStart(l.openedAt, l.rotateEvery)) {
+		return true
+	}
+	return false
+}
+
+// periodStart liefert den Beginn der Periode, in der t liegt.
+// Perioden bis zu einem Tag werden an lokaler Mitternacht ausgerichtet, damit "stündlich" zur Uhr passt.
+func periodStart(t time.Time, every time.Duration) time.Time {
+	if every > 24*time.Hour {
+		return t.Truncate(every)
+	}
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	return midnight.Add(t.Sub(midnight) / every * every)
+}
+
 func (l *Logger) Push(m *model.Measurement) {
-	if m == nil || !l.active || l.csv == nil {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if m == nil || !l.active || l.paused || l.file == nil || len(l.fileWide) > 0 {
 		return
 	}
+	if l.fileAgg > 0 {
+		// jedes Sample zählt ins Fenster, das Intervall gilt hier nicht
+		l.aggregate(m)
+		return
+	}
+	l.writeSample(m)
+}
 
+func (l *Logger) writeSample(m *model.Measurement) {
+	l.writeThrottled(func(now time.Time) error { return l.writeRecord(now, m) })
+}
+
+// writeThrottled schreibt per write eine Zeile, sofern das Intervall es zulässt, und rotiert vorher bei Bedarf.
+func (l *Logger) writeThrottled(write func(now time.Time) error) {
 	if l.interval > 0 && !l.lastWrite.IsZero() {
 		if time.Since(l.lastWrite) < l.interval {
 			return
 		}
 	}
 
+	now := time.Now()
+
+	// Rotation vor dem Schreiben: das auslösende Sample landet in der neuen Datei
+	if l.needsRotate(now) {
+		if err := l.rotate(); err != nil {
+			l.fail("logger rotate", err)
+			return
+		}
+	}
+
+	if err := write(now); err != nil {
+		l.fail("logger write", err)
+		return
+	}
+	l.lastWrite = now
+}
+
+// jsonlRecord: eine Zeile im JSONL-Log, Measurement-Felder flach neben dem Zeitstempel.
+// Raw überdeckt Measurement.RawHex; nil = Feld fehlt (include_raw = false).
+type jsonlRecord struct {
+	Timestamp string `json:"timestamp"`
+	*model.Measurement
+	Raw *string `json:"raw,omitempty"`
+}
+
+func (l *Logger) writeRecord(now time.Time, m *model.Measurement) error {
+	// Zeitpunkt der Messung, nicht des Schreibens; gleich dem "t" in /api/live
+	if !m.Timestamp.IsZero() {
+		now = m.Timestamp
+	}
+	ts := now.Format(l.timeFormat)
+	if l.jsonl != nil {
+		rec := jsonlRecord{Timestamp: ts, Measurement: m}
+		if l.fileRaw {
+			rec.Raw = &m.RawHex
+		}
+		if err := l.jsonl.Encode(rec); err != nil {
+			return err
+		}
+		return l.rowWritten()
+	}
+
+	rec := l.csvRecord(ts, m)
+	if !l.fileRaw {
+		// raw ist die letzte Spalte von csvRecord
+		rec = rec[:len(rec)-1]
+	}
+	if l.fileDisplay {
+		rec = append(rec, displayString(m))
+	}
+	if err := l.csv.Write(rec); err != nil {
+		return err
+	}
+	return l.rowWritten()
+}
+
+// rowWritten zählt eine gepufferte Zeile und flusht nach der eingestellten Strategie.
+func (l *Logger) rowWritten() error {
+	l.pending++
+	if l.flushRows > 0 && l.pending >= l.flushRows {
+		return l.flush()
+	}
+	if l.flushEvery > 0 && l.flushTimer == nil {
+		l.flushTimer = time.AfterFunc(l.flushEvery, l.timedFlush)
+	}
+	return nil
+}
+
+// flush schreibt gepufferte Zeilen in die Datei. Gepuffert wird nur im Speicher,
+// nach einem Absturz fehlen also höchstens die letzten Zeilen, die Datei bleibt lesbar.
+func (l *Logger) flush() error {
+	if l.flushTimer != nil {
+		l.flushTimer.Stop()
+		l.flushTimer = nil
+	}
+	l.pending = 0
+	if l.csv != nil {
+		l.csv.Flush()
+		if err := l.csv.Error(); err != nil {
+			return err
+		}
+	}
+	if l.buf != nil {
+		return l.buf.Flush()
+	}
+	return nil
+}
+
+// timedFlush: Timer-Callback für flushEvery
+func (l *Logger) timedFlush() {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.flushTimer = nil
+	if !l.active || l.pending == 0 {
+		return
+	}
+	if err := l.flush(); err != nil {
+		l.fail("logger flush", err)
+	}
+}
+
+func (l *Logger) csvRecord(ts string, m *model.Measurement) []string {
 	valStr := ""
 	if m.Value != nil {
-		valStr = fmt.Sprintf("%g", *m.Value)
+		valStr = l.formatFloat(*m.Value)
 	}
-
-	record := []string{
+	return []string{
+		ts,
 		valStr,
 		m.ValueStr,
 		m.Unit,
@@ -134,16 +699,75 @@ func (l *Logger) Push(m *model.Measurement) {
 		boolToStr(m.Hold),
 		boolToStr(m.Rel),
 		boolToStr(m.LowBatt),
+		boolToStr(m.Diode),
+		boolToStr(m.Continuity),
+		boolToStr(m.Beep),
+		boolToStr(m.Overload),
 		m.RawHex,
 	}
+}
 
-	if err := l.csv.Write(record); err != nil {
-		fmt.Fprintf(os.Stderr, "logger write error: %v\n", err)
-		l.active = false
-		return
+// Snapshot hängt genau eine Messung an die Tagesdatei snapshots_<datum>.csv an,
+// unabhängig davon, ob das Intervall-Logging läuft. Die Datei wächst über Neustarts hinweg.
+func (l *Logger) Snapshot(m *model.Measurement) error {
+	if m == nil {
+		return errors.New("no measurement")
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if err := os.MkdirAll(l.dir, 0o755); err != nil {
+		return fmt.Errorf("mkdir logs: %w", err)
 	}
-	l.csv.Flush()
-	l.lastWrite = time.Now()
+	now := time.Now()
+	if !m.Timestamp.IsZero() {
+		now = m.Timestamp
+	}
+	name := "snapshots_" + now.Format("2006-01-02") + ".csv"
+	f, err := os.OpenFile(filepath.Join(l.dir, name), os.O_WRONLY|os.O_CREATE|os.O_APPEND, 0o644)
+	if err != nil {
+		return fmt.Errorf("open snapshot file: %w", err)
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	w := csv.NewWriter(f)
+	w.Comma = l.comma
+	if fi.Size() == 0 {
+		_ = w.Write(csvHeader)
+	}
+	_ = w.Write(l.csvRecord(now.Format(l.timeFormat), m))
+	w.Flush()
+	if err := w.Error(); err != nil {
+		_ = f.Close()
+		return fmt.Errorf("write snapshot: %w", err)
+	}
+	return f.Close()
+}
+
+// countingWriter zählt die geschriebenen Bytes für die Größen-Rotation.
+type countingWriter struct {
+	w io.Writer
+	n int64
+}
+
+func (c *countingWriter) Write(p []byte) (int, error) {
+	n, err := c.w.Write(p)
+	c.n += int64(n)
+	return n, err
+}
+
+// displayString: Anzeige wie am Gerät, z.B. "12.34 mV DC" oder "OL Ohm".
+func displayString(m *model.Measurement) string {
+	parts := make([]string, 0, 3)
+	for _, p := range []string{m.ValueStr, m.Unit, m.Mode} {
+		if p != "" {
+			parts = append(parts, p)
+		}
+	}
+	return strings.Join(parts, " ")
 }
 
 func boolToStr(b bool) string {
@@ -154,6 +778,8 @@ func boolToStr(b bool) string {
 }
 
 func (l *Logger) ListFiles() ([]string, error) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
 	if err := os.MkdirAll(l.dir, 0o755); err != nil {
 		return nil, err
 	}
@@ -163,7 +789,7 @@ func (l *Logger) ListFiles() ([]string, error) {
 	}
 	var out []string
 	for _, e := range ents {
-		if e.IsDir() {
+		if e.IsDir() || strings.HasSuffix(e.Name(), MetaSuffix) || strings.HasSuffix(e.Name(), tmpSuffix) {
 			continue
 		}
 		out = append(out, e.Name())
@@ -171,14 +797,79 @@ func (l *Logger) ListFiles() ([]string, error) {
 	return out, nil
 }
 
+// resolveLogPath bildet name auf eine Datei direkt in l.dir ab.
+// Namen mit Separatoren, ".." oder absolute Pfade werden abgelehnt.
+func (l *Logger) resolveLogPath(name string) (string, error) {
+	if name == "" || name == "." || strings.Contains(name, "..") ||
+		strings.ContainsAny(name, `/\`) || filepath.IsAbs(name) || filepath.VolumeName(name) != "" {
+		return "", ErrInvalidName
+	}
+	l.mu.Lock()
+	dir := filepath.Clean(l.dir)
+	l.mu.Unlock()
+	full := filepath.Clean(filepath.Join(dir, name))
+	if filepath.Dir(full) != dir {
+		return "", ErrInvalidName
+	}
+	return full, nil
+}
+
+// ReadFile liest eine Log-Datei; komprimierte (.gz) werden entpackt.
 func (l *Logger) ReadFile(name string) ([]byte, error) {
-	full := filepath.Join(l.dir, name)
-	return os.ReadFile(full)
+	full, err := l.resolveLogPath(name)
+	if err != nil {
+		return nil, err
+	}
+	rc, err := openLog(full)
+	if err != nil {
+		return nil, err
+	}
+	defer rc.Close()
+	return io.ReadAll(rc)
+}
+
+// OpenFile öffnet eine Log-Datei zum Streamen, ohne sie komplett zu laden; .gz bleibt komprimiert.
+func (l *Logger) OpenFile(name string) (io.ReadCloser, error) {
+	full, err := l.resolveLogPath(name)
+	if err != nil {
+		return nil, err
+	}
+	return os.Open(full)
+}
+
+func (l *Logger) DeleteFile(name string) error {
+	full, err := l.resolveLogPath(name)
+	if err != nil {
+		return err
+	}
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active && name == l.currentName {
+		return ErrFileActive
+	}
+	if err := os.Remove(full); err != nil {
+		return err
+	}
+	// zugehörige Sidecar-Datei mit entfernen, falls vorhanden
+	if err := os.Remove(full + MetaSuffix); err != nil && !os.IsNotExist(err) {
+		return err
+	}
+	return nil
 }
 
 func (l *Logger) Tail(name string, maxLines int) ([]string, error) {
-	full := filepath.Join(l.dir, name)
-	f, err := os.Open(full)
+	full, err := l.resolveLogPath(name)
+	if err != nil {
+		return nil, err
+	}
+
+	// aktive Datei: gepufferte Zeilen erst rausschreiben, damit der Tail konsistent endet
+	l.mu.Lock()
+	if l.active && name == l.currentName {
+		_ = l.flush()
+	}
+	l.mu.Unlock()
+	f, err := openLog(full)
 	if err != nil {
 		return nil, err
 	}
@@ -205,4 +896,3 @@ func (l *Logger) Tail(name string, maxLines int) ([]string, error) {
 	}
 	return buf, nil
 }
-
@@ -0,0 +1,283 @@
+package logging
+
+import (
+	"errors"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"hp90epc/model"
+)
+
+func testMeasurement(v float64) *model.Measurement {
+	return &model.Measurement{
+		Timestamp: time.Now(),
+		Value:     &v,
+		ValueStr:  "1.234",
+		Unit:      "V",
+		Mode:      "DC",
+		RawHex:    "16 20 35 4D 5B 61 7F 82 97 A0 B0 C0 D4 E0",
+	}
+}
+
+// TestLoggerConcurrent: Push aus dem Reader-Goroutine gegen Start/Stop/Pause und die lesenden
+// Aufrufe der HTTP-Handler. Sinnvoll vor allem mit go test -race; danach muss jede Datei
+// ein gültiges CSV sein.
+func TestLoggerConcurrent(t *testing.T) {
+	l := NewLogger(t.TempDir(), 0)
+	l.SetFlushEvery(3, 0)
+
+	stop := make(chan struct{})
+	var wg sync.WaitGroup
+	run := func(fn func(i int)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := 0; ; i++ {
+				select {
+				case <-stop:
+					return
+				default:
+					fn(i)
+				}
+			}
+		}()
+	}
+
+	run(func(i int) { l.Push(testMeasurement(float64(i))) })
+	run(func(i int) {
+		switch i % 4 {
+		case 0:
+			_ = l.Start()
+		case 1:
+			_ = l.Pause()
+		case 2:
+			_ = l.Resume()
+		case 3:
+			_ = l.Stop()
+		}
+	})
+	run(func(i int) {
+		st := l.Status()
+		if _, err := l.ListFiles(); err != nil {
+			t.Errorf("list files: %v", err)
+		}
+		if st.File != "" {
+			_, _ = l.Tail(st.File, 5)
+		}
+	})
+	run(func(i int) {
+		l.SetInterval(i % 2)
+		l.SetMaxFileBytes(int64(2048 + i%1024))
+	})
+
+	time.Sleep(300 * time.Millisecond)
+	close(stop)
+	wg.Wait()
+	if err := l.Stop(); err != nil {
+		t.Fatalf("stop: %v", err)
+	}
+
+	files, err := l.ListFiles()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(files) == 0 {
+		t.Fatal("no log files written")
+	}
+	rows := 0
+	for _, name := range files {
+		if !strings.HasSuffix(name, ".csv") {
+			continue
+		}
+		v, err := l.Validate(name)
+		if err != nil {
+			t.Fatalf("validate %s: %v", name, err)
+		}
+		if v.BadRows != 0 || v.Error != "" {
+			t.Errorf("%s: %+v", name, v)
+		}
+		rows += v.Rows
+	}
+	if rows == 0 {
+		t.Error("no rows written")
+	}
+	if st := l.Status(); st.Active || st.LastError != "" {
+		t.Errorf("status after stop = %+v", st)
+	}
+}
+
+func TestResolveLogPathRejectsTraversal(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(dir, 0)
+	secret := filepath.Join(filepath.Dir(dir), "config.json")
+	if err := os.WriteFile(secret, []byte("{}"), 0o644); err != nil {
+		t.Fatal(err)
+	}
+	defer os.Remove(secret)
+
+	bad := []string{
+		"",
+		".",
+		"..",
+		"../config.json",
+		"../../etc/passwd",
+		"..\\config.json",
+		"sub/file.csv",
+		"sub\\file.csv",
+		"/etc/passwd",
+		secret,
+		"a..b.csv",
+		"C:\\Windows\\win.ini",
+	}
+	for _, name := range bad {
+		if full, err := l.resolveLogPath(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("resolveLogPath(%q) = %q, %v; want ErrInvalidName", name, full, err)
+		}
+		if _, err := l.ReadFile(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("ReadFile(%q) err = %v, want ErrInvalidName", name, err)
+		}
+		if _, err := l.Tail(name, 10); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("Tail(%q) err = %v, want ErrInvalidName", name, err)
+		}
+		if err := l.DeleteFile(name); !errors.Is(err, ErrInvalidName) {
+			t.Errorf("DeleteFile(%q) err = %v, want ErrInvalidName", name, err)
+		}
+	}
+	if _, err := os.Stat(secret); err != nil {
+		t.Errorf("file outside the log dir was touched: %v", err)
+	}
+
+	full, err := l.resolveLogPath("hp90epc_2024-01-02_03-04-05.csv")
+	if err != nil || full != filepath.Join(dir, "hp90epc_2024-01-02_03-04-05.csv") {
+		t.Errorf("valid name = %q, %v", full, err)
+	}
+}
+
+func TestLoggerOverloadColumn(t *testing.T) {
+	ol := &model.Measurement{Timestamp: time.Now(), ValueStr: "OL", Unit: "MOhm", Overload: true}
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		l := NewLogger(t.TempDir(), 0)
+		l.SetFormat(format)
+		if err := l.Start(); err != nil {
+			t.Fatal(err)
+		}
+		l.Push(ol)
+		name := l.Status().File
+		if err := l.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := l.ReadFile(name)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		switch format {
+		case FormatCSV:
+			if len(lines) != 2 {
+				t.Fatalf("csv lines = %q", lines)
+			}
+			header := strings.Split(lines[0], ",")
+			row := strings.Split(lines[1], ",")
+			col := slices.Index(header, "overload")
+			if col < 0 || len(row) != len(header) {
+				t.Fatalf("header %q, row %q", header, row)
+			}
+			if row[col] != "1" || row[slices.Index(header, "value")] != "" || row[slices.Index(header, "value_str")] != "OL" {
+				t.Errorf("row = %q, want overload=1, empty value, value_str OL", row)
+			}
+		case FormatJSONL:
+			if len(lines) != 1 || !strings.Contains(lines[0], `"overload":true`) || !strings.Contains(lines[0], `"value":null`) {
+				t.Errorf("jsonl = %q, want overload true and value null", lines)
+			}
+		}
+	}
+}
+
+// TestStartAppendCutsPartialLine: nach einem Absturz mitten im Schreiben darf der erste neue
+// Datensatz nicht an der abgebrochenen Zeile kleben.
+func TestStartAppendCutsPartialLine(t *testing.T) {
+	for _, format := range []string{FormatCSV, FormatJSONL} {
+		dir := t.TempDir()
+		l := NewLogger(dir, 0)
+		l.SetFormat(format)
+		if err := l.Start(); err != nil {
+			t.Fatal(err)
+		}
+		l.Push(testMeasurement(1))
+		l.Push(testMeasurement(2))
+		name := l.Status().File
+		if err := l.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		full := filepath.Join(dir, name)
+		fi, err := os.Stat(full)
+		if err != nil {
+			t.Fatal(err)
+		}
+		if err := os.Truncate(full, fi.Size()-10); err != nil {
+			t.Fatal(err)
+		}
+
+		if err := l.StartAppend(name); err != nil {
+			t.Fatalf("%s: append: %v", format, err)
+		}
+		l.Push(testMeasurement(3))
+		if err := l.Stop(); err != nil {
+			t.Fatal(err)
+		}
+		data, err := os.ReadFile(full)
+		if err != nil {
+			t.Fatal(err)
+		}
+		lines := strings.Split(strings.TrimSuffix(string(data), "\n"), "\n")
+		switch format {
+		case FormatCSV:
+			v, err := l.Validate(name)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if v.BadRows != 0 || v.Rows != 2 {
+				t.Errorf("csv after append: %+v\n%s", v, data)
+			}
+		case FormatJSONL:
+			if len(lines) != 2 {
+				t.Fatalf("jsonl lines = %q", lines)
+			}
+			for _, line := range lines {
+				if !strings.HasPrefix(line, "{") || !strings.HasSuffix(line, "}") {
+					t.Errorf("jsonl line %q is incomplete", line)
+				}
+			}
+		}
+	}
+}
+
+func TestStartAppendRejectsUnterminatedMiddle(t *testing.T) {
+	dir := t.TempDir()
+	l := NewLogger(dir, 0)
+	if err := l.Start(); err != nil {
+		t.Fatal(err)
+	}
+	l.Push(testMeasurement(1))
+	name := l.Status().File
+	if err := l.Stop(); err != nil {
+		t.Fatal(err)
+	}
+	full := filepath.Join(dir, name)
+	f, err := os.OpenFile(full, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		t.Fatal(err)
+	}
+	// kaputte Zeile in der Mitte und abgeschnittenes Ende: nicht reparierbar
+	_, _ = f.WriteString("x\n1,2")
+	_ = f.Close()
+
+	if err := l.StartAppend(name); !errors.Is(err, ErrCannotAppend) {
+		t.Errorf("append = %v, want ErrCannotAppend", err)
+	}
+}
@@ -0,0 +1,54 @@
+package logging
+
+import (
+	"path/filepath"
+	"strings"
+	"time"
+)
+
+// DefaultNamePattern: Vorlage für Log-Dateinamen ohne Endung.
+// Platzhalter: {ts} Startzeit, {port} Port-Name (z.B. "ttyUSB0"), {note} Session-Notiz.
+const DefaultNamePattern = "hp90epc_{ts}"
+
+// maxNoteInName: längere Notizen werden im Dateinamen gekürzt
+const maxNoteInName = 40
+
+// expandName setzt die Platzhalter ein und ersetzt alles, was in Dateinamen Ärger macht.
+// Kollisionen löst openFile über einen angehängten Zähler.
+func (l *Logger) expandName(now time.Time) string {
+	note := []rune(l.note)
+	if len(note) > maxNoteInName {
+		note = note[:maxNoteInName]
+	}
+	port := ""
+	if l.port != "" {
+		port = filepath.Base(filepath.Clean(l.port))
+	}
+	name := strings.NewReplacer(
+		"{ts}", now.Format("2006-01-02_15-04-05"),
+		"{port}", port,
+		"{note}", string(note),
+	).Replace(l.namePattern)
+
+	name = sanitizeName(name)
+	if name == "" {
+		return sanitizeName(strings.ReplaceAll(DefaultNamePattern, "{ts}", now.Format("2006-01-02_15-04-05")))
+	}
+	return name
+}
+
+// sanitizeName ersetzt Pfadtrenner, unter Windows verbotene Zeichen, Steuerzeichen und
+// Leerraum durch "_" und entfernt Punkte/Unterstriche am Rand (kein ".." oder versteckte Dateien).
+func sanitizeName(s string) string {
+	var b strings.Builder
+	for _, r := range s {
+		switch {
+		case r < 0x20 || r == 0x7f, strings.ContainsRune(`/\:*?"<>| `, r):
+			b.WriteRune('_')
+		default:
+			b.WriteRune(r)
+		}
+	}
+	out := strings.ReplaceAll(b.String(), "..", "_")
+	return strings.Trim(out, "._")
+}
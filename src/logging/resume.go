@@ -0,0 +1,146 @@
+package logging
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/csv"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"slices"
+	"strings"
+	"time"
+)
+
+// ErrCannotAppend: die Datei passt nicht zu den aktuellen Einstellungen (Format, Spalten).
+var ErrCannotAppend = errors.New("log file does not match current settings")
+
+// StartAppend setzt das Logging in der vorhandenen Datei name fort, z.B. nach einem Absturz.
+// Fehlt die Datei (fs.ErrNotExist) oder passt sie nicht (ErrCannotAppend), bleibt das Logging aus
+// und der Aufrufer entscheidet, ob er mit Start eine neue Session beginnt.
+func (l *Logger) StartAppend(name string) error {
+	full, err := l.resolveLogPath(name)
+	if err != nil {
+		return err
+	}
+
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if l.active {
+		return nil
+	}
+	if filepath.Ext(name) != "."+l.format {
+		return ErrCannotAppend
+	}
+	if l.format == FormatCSV {
+		if err := l.checkHeader(full); err != nil {
+			return err
+		}
+	}
+	if err := l.cutPartialLine(full); err != nil {
+		return err
+	}
+
+	f, err := os.OpenFile(full, os.O_WRONLY|os.O_APPEND, 0)
+	if err != nil {
+		return err
+	}
+	fi, err := f.Stat()
+	if err != nil {
+		_ = f.Close()
+		return err
+	}
+
+	buf := bufio.NewWriter(f)
+	out := &countingWriter{w: buf, n: fi.Size()}
+	l.csv = nil
+	l.jsonl = nil
+	if l.format == FormatJSONL {
+		l.jsonl = json.NewEncoder(out)
+	} else {
+		w := csv.NewWriter(out)
+		w.Comma = l.comma
+		l.csv = w
+	}
+	l.file = f
+	l.buf = buf
+	l.out = out
+	l.currentName = name
+	// Start aus der Sidecar-Datei, damit die Zeit-Rotation an der ursprünglichen Periode bleibt
+	l.openedAt = fi.ModTime()
+	if meta, ok := readMeta(full + MetaSuffix); ok {
+		l.openedAt = meta.Started
+		l.note = meta.Note
+	}
+	l.fileSettings()
+
+	l.lastWrite = time.Time{}
+	l.active = true
+	l.lastErr = ""
+	return nil
+}
+
+// checkHeader vergleicht die Kopfzeile der Datei (nach einer evtl. Kommentarzeile) mit der aktuellen.
+func (l *Logger) checkHeader(full string) error {
+	f, err := os.Open(full)
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+
+	r := csv.NewReader(f)
+	r.Comma = l.comma
+	r.Comment = '#'
+	r.FieldsPerRecord = -1
+	got, err := r.Read()
+	if err != nil {
+		return fmt.Errorf("%w: %v", ErrCannotAppend, err)
+	}
+	if !slices.Equal(got, l.header()) {
+		return fmt.Errorf("%w: header %q", ErrCannotAppend, strings.Join(got, string(l.comma)))
+	}
+	return nil
+}
+
+// cutPartialLine kürzt die Datei um eine beim Absturz abgebrochene letzte Zeile, damit der erste
+// neue Datensatz nicht an ihr klebt. CSV wie ReadFileRepaired; bleibt danach eine Zeile ohne '\n'
+// (z.B. Fehler mitten in der Datei), passt die Datei nicht (ErrCannotAppend).
+func (l *Logger) cutPartialLine(full string) error {
+	data, err := os.ReadFile(full)
+	if err != nil {
+		return err
+	}
+	keep := len(data)
+	if l.format == FormatCSV {
+		if v, k := checkCSV(data, l.comma); v.Repairable {
+			keep = k
+		}
+	} else if keep > 0 && data[keep-1] != '\n' {
+		// JSONL: jede Zeile steht für sich, der Rest nach dem letzten '\n' ist unvollständig
+		keep = bytes.LastIndexByte(data, '\n') + 1
+	}
+	if keep > 0 && data[keep-1] != '\n' {
+		return fmt.Errorf("%w: last line is not terminated", ErrCannotAppend)
+	}
+	if keep == len(data) {
+		return nil
+	}
+	slog.Warn("cut incomplete last line", "file", filepath.Base(full), "bytes", len(data)-keep)
+	return os.Truncate(full, int64(keep))
+}
+
+// readMeta liest die Sidecar-Datei; false, wenn sie fehlt oder kaputt ist.
+func readMeta(path string) (SessionMeta, bool) {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return SessionMeta{}, false
+	}
+	var meta SessionMeta
+	if err := json.Unmarshal(b, &meta); err != nil || meta.Started.IsZero() {
+		return SessionMeta{}, false
+	}
+	return meta, true
+}
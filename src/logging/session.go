@@ -0,0 +1,138 @@
+package logging
+
+import (
+	"encoding/json"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// MetaSuffix: Sidecar-Datei mit den Session-Daten neben jeder Log-Datei, z.B. "<datei>.csv.meta.json".
+const MetaSuffix = ".meta.json"
+
+// SessionMeta: Inhalt der Sidecar-Datei, damit archivierte Logs selbsterklärend bleiben.
+type SessionMeta struct {
+	File       string    `json:"file"`
+	Started    time.Time `json:"started"`
+	Port       string    `json:"port"`
+	Baud       int       `json:"baud"`
+	IntervalMs int       `json:"interval_ms"`
+	Note       string    `json:"note"`
+}
+
+// FileNote: Log-Datei samt Notiz aus der Sidecar-Datei (leer ohne Sidecar).
+type FileNote struct {
+	Name string `json:"name"`
+	Note string `json:"note"`
+}
+
+// FileInfo: Log-Datei mit Größe, Änderungszeit und Session-Notiz für /api/log/files?detailed=1.
+type FileInfo struct {
+	Name    string    `json:"name"`
+	Size    int64     `json:"size"`
+	ModTime time.Time `json:"mod_time"`
+	Note    string    `json:"note"`
+}
+
+// ListFilesDetailed: wie ListFiles mit Größe und Änderungszeit, neueste zuerst.
+func (l *Logger) ListFilesDetailed() ([]FileInfo, error) {
+	names, err := l.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	dir := l.dir
+	l.mu.Unlock()
+
+	out := make([]FileInfo, 0, len(names))
+	for _, name := range names {
+		fi, err := os.Stat(filepath.Join(dir, name))
+		if err != nil {
+			// zwischendurch gelöscht
+			continue
+		}
+		out = append(out, FileInfo{
+			Name:    name,
+			Size:    fi.Size(),
+			ModTime: fi.ModTime(),
+			Note:    readNote(filepath.Join(dir, name+MetaSuffix)),
+		})
+	}
+	sort.Slice(out, func(i, j int) bool {
+		if !out[i].ModTime.Equal(out[j].ModTime) {
+			return out[i].ModTime.After(out[j].ModTime)
+		}
+		return out[i].Name > out[j].Name
+	})
+	return out, nil
+}
+
+// SetSource hinterlegt Port und Baudrate des Geräts für die Sidecar-Datei der nächsten Session.
+func (l *Logger) SetSource(port string, baud int) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.port = port
+	l.baud = baud
+}
+
+// SetNote setzt die Notiz der laufenden Session (Sidecar wird neu geschrieben) oder,
+// bei gestopptem Logging, der nächsten. Stop verwirft die Notiz.
+func (l *Logger) SetNote(note string) error {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.note = strings.TrimSpace(note)
+	if l.active {
+		return l.writeMeta()
+	}
+	return nil
+}
+
+// writeMeta schreibt die Sidecar-Datei zur offenen Log-Datei.
+func (l *Logger) writeMeta() error {
+	meta := SessionMeta{
+		File:       l.currentName,
+		Started:    l.openedAt,
+		Port:       l.port,
+		Baud:       l.baud,
+		IntervalMs: int(l.interval / time.Millisecond),
+		Note:       l.note,
+	}
+	b, err := json.MarshalIndent(meta, "", "  ")
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(filepath.Join(l.dir, l.currentName+MetaSuffix), b, 0o644)
+}
+
+// ListFilesWithNotes: wie ListFiles, dazu die Notiz jeder Datei.
+func (l *Logger) ListFilesWithNotes() ([]FileNote, error) {
+	names, err := l.ListFiles()
+	if err != nil {
+		return nil, err
+	}
+	l.mu.Lock()
+	dir := l.dir
+	l.mu.Unlock()
+
+	out := make([]FileNote, 0, len(names))
+	for _, name := range names {
+		out = append(out, FileNote{Name: name, Note: readNote(filepath.Join(dir, name+MetaSuffix))})
+	}
+	return out, nil
+}
+
+func readNote(path string) string {
+	b, err := os.ReadFile(path)
+	if err != nil {
+		return ""
+	}
+	var meta SessionMeta
+	if err := json.Unmarshal(b, &meta); err != nil {
+		slog.Warn("read session meta", "file", path, "err", err)
+		return ""
+	}
+	return meta.Note
+}
@@ -0,0 +1,170 @@
+package logging
+
+import (
+	"bytes"
+	"encoding/csv"
+	"errors"
+	"io"
+	"strings"
+)
+
+// ErrNotCSV: Validate/ReadFileRepaired gibt es nur für CSV-Dateien.
+var ErrNotCSV = errors.New("not a CSV log file")
+
+// Validation: Ergebnis von Validate.
+type Validation struct {
+	Name string `json:"name"`
+	// Rows: fehlerfreie Datenzeilen ohne Kopf- und Kommentarzeile
+	Rows    int `json:"rows"`
+	Columns int `json:"columns"`
+	// BadRows: Zeilen mit falscher Spaltenzahl oder kaputten Anführungszeichen
+	BadRows int `json:"bad_rows"`
+	// FirstBadLine: Zeilennummer (ab 1) der ersten fehlerhaften Zeile, 0 = keine
+	FirstBadLine int    `json:"first_bad_line,omitempty"`
+	Error        string `json:"error,omitempty"`
+	// Repairable: nur die letzte Zeile ist unvollständig (abgebrochener Schreibvorgang);
+	// ReadFileRepaired lässt sie weg
+	Repairable bool `json:"repairable"`
+}
+
+// Validate prüft eine CSV-Log-Datei Zeile für Zeile auf eine einheitliche Spaltenzahl.
+func (l *Logger) Validate(name string) (Validation, error) {
+	data, err := l.readCSV(name)
+	if err != nil {
+		return Validation{}, err
+	}
+	v, _ := checkCSV(data, l.delimiter())
+	v.Name = name
+	return v, nil
+}
+
+// ReadFileRepaired liest wie ReadFile, lässt aber eine unvollständige letzte Zeile weg.
+// Fehler mitten in der Datei bleiben unverändert stehen; v beschreibt den Zustand vor der Reparatur.
+func (l *Logger) ReadFileRepaired(name string) ([]byte, Validation, error) {
+	data, err := l.readCSV(name)
+	if err != nil {
+		return nil, Validation{}, err
+	}
+	v, keep := checkCSV(data, l.delimiter())
+	v.Name = name
+	if v.Repairable {
+		data = data[:keep]
+	}
+	return data, v, nil
+}
+
+func (l *Logger) readCSV(name string) ([]byte, error) {
+	if err := csvName(name); err != nil {
+		return nil, err
+	}
+	return l.ReadFile(name)
+}
+
+// csvName: ErrNotCSV für alles außer .csv bzw. .csv.gz.
+func csvName(name string) error {
+	if !strings.HasSuffix(strings.TrimSuffix(name, GzipSuffix), "."+FormatCSV) {
+		return ErrNotCSV
+	}
+	return nil
+}
+
+func (l *Logger) delimiter() rune {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.comma
+}
+
+// checkCSV liest data mit dem Trennzeichen der Kopfzeile und liefert das Ergebnis sowie die Länge
+// von data ohne die letzte Zeile (nur bei Repairable sinnvoll).
+func checkCSV(data []byte, comma rune) (Validation, int) {
+	r := csv.NewReader(bytes.NewReader(data))
+	r.Comma = sniffComma(data, comma)
+	r.Comment = '#'
+	// 0: die Kopfzeile legt die Spaltenzahl fest, abweichende Zeilen liefern ErrFieldCount
+	r.FieldsPerRecord = 0
+	r.ReuseRecord = true
+
+	var v Validation
+	header := true
+	keep := 0 // Ende der letzten fehlerfreien Zeile
+	for {
+		start := int(r.InputOffset())
+		rec, err := r.Read()
+		if err == io.EOF {
+			break
+		}
+		if err != nil {
+			v.BadRows++
+			if v.FirstBadLine == 0 {
+				v.FirstBadLine = lineOf(err)
+				v.Error = err.Error()
+				keep = start
+			}
+			// nach kaputten Anführungszeichen ist der Rest nicht mehr zuverlässig lesbar
+			if !errors.Is(err, csv.ErrFieldCount) {
+				if _, err := r.Read(); err != io.EOF {
+					v.BadRows++
+				}
+				break
+			}
+			continue
+		}
+		if header {
+			v.Columns = len(rec)
+			header = false
+			continue
+		}
+		v.Rows++
+	}
+
+	// der Logger schließt jede Zeile mit '\n' ab; fehlt es, wurde die letzte Zeile abgeschnitten,
+	// auch wenn die Spaltenzahl zufällig stimmt
+	if v.BadRows == 0 && len(data) > 0 && data[len(data)-1] != '\n' && v.Rows > 0 {
+		v.Rows--
+		v.BadRows = 1
+		v.FirstBadLine = bytes.Count(data, []byte{'\n'}) + 1
+		v.Error = "last line is not terminated"
+		keep = bytes.LastIndexByte(data, '\n') + 1
+	}
+	v.Repairable = v.BadRows == 1 && lastLine(data, keep)
+	return v, keep
+}
+
+// lastLine meldet, ob ab keep nur noch eine (unvollständige) Zeile folgt.
+func lastLine(data []byte, keep int) bool {
+	rest := bytes.TrimRight(data[keep:], "\r\n")
+	return len(rest) > 0 && bytes.IndexByte(rest, '\n') < 0
+}
+
+// sniffComma: comma, wenn es in der Kopfzeile vorkommt, sonst ',', ';' oder Tab –
+// die Datei kann mit einem anderen Trennzeichen geschrieben worden sein.
+func sniffComma(data []byte, comma rune) rune {
+	head := data
+	for bytes.HasPrefix(head, []byte{'#'}) {
+		i := bytes.IndexByte(head, '\n')
+		if i < 0 {
+			return comma
+		}
+		head = head[i+1:]
+	}
+	if i := bytes.IndexByte(head, '\n'); i >= 0 {
+		head = head[:i]
+	}
+	if bytes.ContainsRune(head, comma) {
+		return comma
+	}
+	for _, c := range []rune{',', ';', '\t'} {
+		if bytes.ContainsRune(head, c) {
+			return c
+		}
+	}
+	return comma
+}
+
+func lineOf(err error) int {
+	var pe *csv.ParseError
+	if errors.As(err, &pe) {
+		return pe.StartLine
+	}
+	return 0
+}
@@ -0,0 +1,90 @@
+package logging
+
+import (
+	"slices"
+	"time"
+
+	"hp90epc/model"
+)
+
+// Spalten je Quelle im breiten Format, jeweils mit "<quelle>_" davor.
+var wideFields = []string{"value", "value_str", "unit", "mode"}
+
+// wideRecord: eine Zeile im breiten JSONL-Log; fehlende Quellen sind null.
+type wideRecord struct {
+	Timestamp string                        `json:"timestamp"`
+	Sources   map[string]*model.Measurement `json:"sources"`
+}
+
+// SetWideColumns schaltet auf das breite Format um: eine Zeile je Zeitpunkt mit Spalten
+// <quelle>_value, _value_str, _unit, _mode für jede Quelle in sources (z.B. Geräte-IDs).
+// Geschrieben wird dann nur noch über PushAll, Push und der Aggregat-Modus ruhen.
+// nil = langes Format (Default). Greift ab der nächsten Datei.
+func (l *Logger) SetWideColumns(sources []string) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	l.wide = slices.Clone(sources)
+}
+
+// Wide meldet, ob gerade eine Datei im breiten Format geschrieben wird.
+func (l *Logger) Wide() bool {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	return l.active && len(l.fileWide) > 0
+}
+
+// PushAll schreibt die Messungen aller Quellen in eine Zeile (Intervall wie bei Push).
+// Quellen ohne Eintrag bleiben leer, unbekannte Quellen werden ignoriert.
+func (l *Logger) PushAll(ms map[string]*model.Measurement) {
+	l.mu.Lock()
+	defer l.mu.Unlock()
+	if !l.active || l.paused || l.file == nil || len(l.fileWide) == 0 {
+		return
+	}
+	l.writeThrottled(func(now time.Time) error { return l.writeWide(now, ms) })
+}
+
+func (l *Logger) writeWide(now time.Time, ms map[string]*model.Measurement) error {
+	ts := now.Format(l.timeFormat)
+	if l.jsonl != nil {
+		rec := wideRecord{Timestamp: ts, Sources: make(map[string]*model.Measurement, len(l.fileWide))}
+		for _, src := range l.fileWide {
+			rec.Sources[src] = ms[src]
+		}
+		if err := l.jsonl.Encode(rec); err != nil {
+			return err
+		}
+		return l.rowWritten()
+	}
+
+	rec := make([]string, 0, 1+len(l.fileWide)*len(wideFields))
+	rec = append(rec, ts)
+	for _, src := range l.fileWide {
+		m := ms[src]
+		if m == nil {
+			rec = append(rec, "", "", "", "")
+			continue
+		}
+		val := ""
+		if m.Value != nil {
+			val = l.formatFloat(*m.Value)
+		}
+		rec = append(rec, val, m.ValueStr, m.Unit, m.Mode)
+	}
+	if err := l.csv.Write(rec); err != nil {
+		return err
+	}
+	return l.rowWritten()
+}
+
+// wideHeader: "timestamp", dann je Quelle die wideFields mit Präfix.
+func wideHeader(sources []string) []string {
+	h := make([]string, 0, 1+len(sources)*len(wideFields))
+	h = append(h, "timestamp")
+	for _, src := range sources {
+		for _, f := range wideFields {
+			h = append(h, src+"_"+f)
+		}
+	}
+	return h
+}
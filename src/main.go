@@ -1,17 +1,24 @@
 package main
 
 import (
+	"context"
+	"errors"
 	"flag"
 	"fmt"
-	"log"
+	"log/slog"
+	"net"
 	"os"
 	"os/exec"
+	"os/signal"
 	"path/filepath"
+	"reflect"
 	"runtime"
 	"strings"
 	"sync"
+	"syscall"
 	"time"
 
+	"hp90epc/buildinfo"
 	"hp90epc/config"
 	"hp90epc/logging"
 	"hp90epc/model"
@@ -20,49 +27,441 @@ import (
 )
 
 type app struct {
-	latest *model.LatestBuffer
-	mgr    *reader.Manager
-	logger *logging.Logger
+	// Standardgerät (DevicePort); seine Methoden bedienen Requests ohne ?dev=
+	*device
+	// weitere Geräte aus cfg.Devices, IDs fest ab Start
+	extra map[string]*device
 
 	cfg    config.Config
 	appDir string
 	cfgMu  sync.Mutex
+	// -sim aktiv: der Standard-Reader läuft auf reader.SimPort statt cfg.DevicePort
+	sim bool
 }
 
-func (a *app) GetLatest() *model.Measurement  { return a.latest.Get() }
-func (a *app) GetReaderStatus() reader.Status { return a.mgr.GetStatus() }
+// readerPort liefert den Port für einen Neustart des Standard-Readers. Unter -sim
+// bleibt der Simulator, bis ein anderer Port als der konfigurierte gewählt wird.
+func (a *app) readerPort(port string) string {
+	a.cfgMu.Lock()
+	defer a.cfgMu.Unlock()
+	if a.sim && port == a.cfg.DevicePort {
+		return reader.SimPort
+	}
+	a.sim = false
+	return port
+}
+
+// DeviceIDs: Standardgerät zuerst, dann die weiteren in Config-Reihenfolge.
+func (a *app) DeviceIDs() []string {
+	cfg := a.GetConfig()
+	ids := []string{config.DeviceID(cfg.DevicePort)}
+	for _, d := range cfg.Devices {
+		if _, ok := a.extra[config.DeviceID(d.Port)]; ok {
+			ids = append(ids, config.DeviceID(d.Port))
+		}
+	}
+	return ids
+}
+
+func (a *app) LookupDevice(id string) (server.Device, bool) {
+	return a.lookup(id)
+}
+
+// LookupLogFiles: Log-Dateien des Geräts id aus dessen eigenem Verzeichnis ("" = Standardgerät).
+func (a *app) LookupLogFiles(id string) (server.LogFiles, bool) {
+	return a.lookup(id)
+}
+
+func (a *app) lookup(id string) (*device, bool) {
+	if id == "" || id == config.DeviceID(a.GetConfig().DevicePort) {
+		return a.device, true
+	}
+	d, ok := a.extra[id]
+	return d, ok
+}
+
+// all liefert alle Geräte, das Standardgerät zuerst.
+func (a *app) all() []*device {
+	out := []*device{a.device}
+	for _, d := range a.extra {
+		out = append(out, d)
+	}
+	return out
+}
+
+// forExtra wendet fn auf die weiteren Geräte an; Fehler werden nur geloggt,
+// maßgeblich für die API-Antwort ist das Standardgerät.
+func (a *app) forExtra(op string, fn func(d *device) error) {
+	for id, d := range a.extra {
+		if err := fn(d); err != nil {
+			slog.Warn(op, "device", id, "err", err)
+		}
+	}
+}
+func (a *app) DecodeFrame(frame []byte) (*model.Measurement, error) {
+	m, err := reader.DecodeFrame(a.mgr.Protocol(), frame)
+	a.mgr.UnitStyle().Apply(m)
+	return m, err
+}
 func (a *app) SetDevice(port string, baud int) error {
-	if err := a.mgr.SetPort(port, baud); err != nil {
+	if err := config.CheckBaud(baud); err != nil {
+		return err
+	}
+	if err := a.mgr.SetPort(a.readerPort(port), baud); err != nil {
 		return err
 	}
+	a.alarms.SetDevice(config.DeviceID(port))
 	a.cfgMu.Lock()
 	a.cfg.DevicePort = port
-	a.cfg.Baud = baud
+	a.cfg.Baud = config.Baud(baud)
 	a.cfgMu.Unlock()
 	a.saveConfig()
 	return nil
 }
-func (a *app) GetLogStatus() logging.LogStatus { return a.logger.Status() }
+func (a *app) ListPorts() ([]string, error) { return reader.ListPorts() }
+func (a *app) TestDevice(ctx context.Context, port string, baud int, timeout time.Duration) (reader.TestResult, error) {
+	for _, d := range a.all() {
+		if st := d.mgr.GetStatus(); st.PortOpen && st.Port == port {
+			return reader.TestResult{}, reader.ErrPortBusy
+		}
+	}
+	res := reader.TestPort(ctx, a.mgr.Protocol(), port, baud, timeout)
+	if res.Sample != nil {
+		a.mgr.UnitStyle().Apply(res.Sample)
+	}
+	return res, nil
+}
+func (a *app) SetStaleAfter(ms int) error {
+	for _, d := range a.all() {
+		if err := d.mgr.SetStaleAfter(time.Duration(ms) * time.Millisecond); err != nil {
+			return err
+		}
+	}
+	a.cfgMu.Lock()
+	a.cfg.StaleAfterMs = ms
+	a.cfgMu.Unlock()
+	a.saveConfig()
+	return nil
+}
+
+// Start/Stop/Pause/Resume und Intervall gelten für die Logger aller Geräte gemeinsam.
+// Im breiten Format schreibt nur der Logger des Standardgeräts, mit Spalten für alle Geräte.
 func (a *app) LogStart() (logging.LogStatus, error) {
+	for _, d := range a.all() {
+		st := d.mgr.GetStatus()
+		d.logger.SetSource(st.Port, st.Baud)
+	}
+	wide := a.wideSources()
+	a.logger.SetWideColumns(wide)
 	err := a.logger.Start()
+	if wide == nil {
+		a.forExtra("start logging", func(d *device) error { return d.logger.Start() })
+	}
+	if err == nil {
+		a.setActiveLogFile(a.logger.Status().File)
+	}
 	return a.logger.Status(), err
 }
 
 func (a *app) LogStop() (logging.LogStatus, error) {
 	err := a.logger.Stop()
+	a.forExtra("stop logging", func(d *device) error { return d.logger.Stop() })
+	a.setActiveLogFile("")
+	return a.logger.Status(), err
+}
+
+// setActiveLogFile merkt sich die laufende Log-Datei in der Config, damit LogAutoStart sie nach
+// einem Absturz oder Neustart fortsetzen kann; "" = Logging wurde bewusst gestoppt.
+func (a *app) setActiveLogFile(name string) {
+	a.cfgMu.Lock()
+	changed := a.cfg.LogActiveFile != name
+	a.cfg.LogActiveFile = name
+	a.cfgMu.Unlock()
+	if changed {
+		a.saveConfig()
+	}
+}
+
+// autoStartLogging setzt beim Start die zuletzt aktive Datei fort oder beginnt eine neue Session,
+// wenn sie fehlt oder nicht zu den Einstellungen passt. Zusätzliche Geräte beginnen immer neu.
+func (a *app) autoStartLogging() {
+	if name := a.GetConfig().LogActiveFile; name != "" {
+		for _, d := range a.all() {
+			st := d.mgr.GetStatus()
+			d.logger.SetSource(st.Port, st.Baud)
+		}
+		wide := a.wideSources()
+		a.logger.SetWideColumns(wide)
+		err := a.logger.StartAppend(name)
+		if err == nil {
+			if wide == nil {
+				a.forExtra("start logging", func(d *device) error { return d.logger.Start() })
+			}
+			slog.Info("logging resumed", "file", name)
+			return
+		}
+		slog.Warn("cannot resume log file, starting a new one", "file", name, "err", err)
+	}
+	st, err := a.LogStart()
+	if err != nil {
+		slog.Error("auto-start logging", "err", err)
+		return
+	}
+	slog.Info("logging started", "file", st.File)
+}
+
+// wideSources: Spalten für log_layout "wide" (alle Geräte-IDs), nil = langes Format.
+func (a *app) wideSources() []string {
+	if a.GetConfig().LogLayout != "wide" {
+		return nil
+	}
+	return a.DeviceIDs()
+}
+
+// pushWide schreibt im breiten Format eine Zeile mit der letzten Messung jedes verbundenen Geräts;
+// getrennte Geräte bleiben leer. Läuft bei jeder neuen Messung eines Geräts, das Intervall drosselt.
+func (a *app) pushWide() {
+	if !a.logger.Wide() {
+		return
+	}
+	ms := map[string]*model.Measurement{}
+	for _, id := range a.DeviceIDs() {
+		dev, ok := a.lookup(id)
+		if !ok || !dev.GetReaderStatus().Connected {
+			continue
+		}
+		// am Live-Puffer vorbei an Freeze: geloggt wird immer der aktuelle Wert
+		if m := dev.latest.Get(); m != nil {
+			ms[id] = m
+		}
+	}
+	a.logger.PushAll(ms)
+}
+
+func (a *app) LogPause() (logging.LogStatus, error) {
+	err := a.logger.Pause()
+	a.forExtra("pause logging", func(d *device) error { return d.logger.Pause() })
+	return a.logger.Status(), err
+}
+
+func (a *app) LogResume() (logging.LogStatus, error) {
+	err := a.logger.Resume()
+	a.forExtra("resume logging", func(d *device) error { return d.logger.Resume() })
+	return a.logger.Status(), err
+}
+
+// LogSetNote setzt die Session-Notiz für alle Geräte.
+func (a *app) LogSetNote(note string) (logging.LogStatus, error) {
+	err := a.logger.SetNote(note)
+	a.forExtra("set log note", func(d *device) error { return d.logger.SetNote(note) })
 	return a.logger.Status(), err
 }
+
+// LogSetDir wechselt das Log-Verzeichnis (nur bei gestopptem Logging) und speichert es in der Config.
+func (a *app) LogSetDir(dir string) (logging.LogStatus, error) {
+	base := resolveLogDir(a.appDir, dir)
+	if err := a.logger.SetDir(base); err != nil {
+		return a.logger.Status(), err
+	}
+	for id, d := range a.extra {
+		if err := d.logger.SetDir(extraLogDir(base, id)); err != nil {
+			slog.Warn("set log dir", "device", id, "err", err)
+		}
+	}
+	a.cfgMu.Lock()
+	a.cfg.LogDir = dir
+	a.cfgMu.Unlock()
+	a.saveConfig()
+	return a.logger.Status(), nil
+}
+
+// LogSnapshot schreibt die aktuelle Messung in die Snapshot-Datei; nil ohne Messung.
+func (a *app) LogSnapshot() (*model.Measurement, error) {
+	m := a.latest.Get()
+	if m == nil {
+		return nil, nil
+	}
+	return m, a.logger.Snapshot(m)
+}
 func (a *app) LogSetInterval(ms int) error {
-	a.logger.SetInterval(ms)
+	if ms < 0 {
+		return fmt.Errorf("interval must be >= 0, got %d", ms)
+	}
+	for _, d := range a.all() {
+		d.logger.SetInterval(ms)
+	}
 	a.cfgMu.Lock()
 	a.cfg.LogIntervalMs = ms
 	a.cfgMu.Unlock()
 	a.saveConfig()
 	return nil
 }
-func (a *app) LogListFiles() ([]string, error)              { return a.logger.ListFiles() }
-func (a *app) LogReadFile(name string) ([]byte, error)      { return a.logger.ReadFile(name) }
-func (a *app) LogTail(name string, n int) ([]string, error) { return a.logger.Tail(name, n) }
+
+func (a *app) GetConfig() config.Config {
+	a.cfgMu.Lock()
+	defer a.cfgMu.Unlock()
+	return a.cfg
+}
+
+// UpdateConfig übernimmt next, wendet zur Laufzeit änderbare Felder direkt an
+// und liefert die Felder, die erst nach einem Neustart greifen.
+func (a *app) UpdateConfig(next config.Config) ([]string, error) {
+	a.cfgMu.Lock()
+	cur := a.cfg
+	a.cfgMu.Unlock()
+
+	restart := []string{}
+	if next.DevicePort != cur.DevicePort || next.Baud != cur.Baud {
+		if err := a.mgr.SetPort(a.readerPort(next.DevicePort), int(next.Baud)); err != nil {
+			return nil, err
+		}
+	}
+	if next.Protocol != cur.Protocol || !reflect.DeepEqual(next.Decode, cur.Decode) {
+		proto, err := protocolFor(next)
+		if err != nil {
+			return nil, err
+		}
+		a.mgr.SetProtocol(proto)
+		if err := a.mgr.SetPort(a.readerPort(next.DevicePort), int(next.Baud)); err != nil {
+			return nil, err
+		}
+		a.forExtra("restart reader", func(d *device) error {
+			d.mgr.SetProtocol(proto)
+			st := d.mgr.GetStatus()
+			return d.mgr.SetPort(st.Port, st.Baud)
+		})
+	}
+	if next.MaxFrameHz != cur.MaxFrameHz || unitStyle(next) != unitStyle(cur) || next.ReadBufferSize != cur.ReadBufferSize {
+		// greift erst mit neuem RunLoop
+		for _, d := range a.all() {
+			d.mgr.SetMaxFrameHz(next.MaxFrameHz)
+			d.mgr.SetUnitStyle(unitStyle(next))
+			d.mgr.SetReadBufSize(next.ReadBufferSize)
+			st := d.mgr.GetStatus()
+			if err := d.mgr.SetPort(st.Port, st.Baud); err != nil {
+				return nil, err
+			}
+		}
+	}
+	if next.WatchdogTimeoutMs != cur.WatchdogTimeoutMs {
+		for _, d := range a.all() {
+			d.mgr.SetWatchdog(time.Duration(next.WatchdogTimeoutMs) * time.Millisecond)
+		}
+	}
+	if next.StaleAfterMs != cur.StaleAfterMs {
+		for _, d := range a.all() {
+			if err := d.mgr.SetStaleAfter(time.Duration(next.StaleAfterMs) * time.Millisecond); err != nil {
+				return nil, err
+			}
+		}
+	}
+	// Logger-Einstellungen sind idempotent, neue Formate greifen ab der nächsten Datei
+	for _, d := range a.all() {
+		configureLogger(d.logger, next)
+		configureAlarms(d.alarms, next)
+		configureEvents(d.events, d.logger, next)
+	}
+	if next.DevicePort != cur.DevicePort {
+		a.alarms.SetDevice(config.DeviceID(next.DevicePort))
+	}
+	if next.LogLevel != cur.LogLevel {
+		if err := setLogLevel(next.LogLevel); err != nil {
+			return nil, err
+		}
+	}
+	if next.LogDir != cur.LogDir {
+		base := resolveLogDir(a.appDir, next.LogDir)
+		if err := a.logger.SetDir(base); err != nil {
+			if !errors.Is(err, logging.ErrLoggingActive) {
+				return nil, err
+			}
+			// laufende Datei nicht abreißen
+			restart = append(restart, "log_dir")
+		} else {
+			for id, d := range a.extra {
+				_ = d.logger.SetDir(extraLogDir(base, id))
+			}
+		}
+	}
+	if !reflect.DeepEqual(next.Devices, cur.Devices) {
+		restart = append(restart, "devices")
+	}
+	if next.HTTPAddr != cur.HTTPAddr {
+		restart = append(restart, "http_addr")
+	}
+	if next.BasePath != cur.BasePath {
+		restart = append(restart, "base_path")
+	}
+	if next.TLSCertFile != cur.TLSCertFile || next.TLSKeyFile != cur.TLSKeyFile || next.TLSSelfSigned != cur.TLSSelfSigned {
+		restart = append(restart, "tls")
+	}
+	if next.HTTPReadTimeoutMs != cur.HTTPReadTimeoutMs || next.HTTPWriteTimeoutMs != cur.HTTPWriteTimeoutMs ||
+		next.HTTPIdleTimeoutMs != cur.HTTPIdleTimeoutMs || next.HTTPMaxBodyBytes != cur.HTTPMaxBodyBytes {
+		restart = append(restart, "http_limits")
+	}
+	if next.HistorySize != cur.HistorySize {
+		restart = append(restart, "history_size")
+	}
+	if next.EventLogSize != cur.EventLogSize {
+		restart = append(restart, "event_log_size")
+	}
+	if next.AuthToken != cur.AuthToken || next.BasicAuthUser != cur.BasicAuthUser ||
+		next.BasicAuthPass != cur.BasicAuthPass || next.AuthProtectReads != cur.AuthProtectReads {
+		restart = append(restart, "auth")
+	}
+	if strings.Join(next.CORSOrigins, ",") != strings.Join(cur.CORSOrigins, ",") {
+		restart = append(restart, "cors_origins")
+	}
+
+	a.cfgMu.Lock()
+	// Laufzeitzustand, nicht über die API setzbar
+	next.LogActiveFile = a.cfg.LogActiveFile
+	a.cfg = next
+	a.cfgMu.Unlock()
+	a.saveConfig()
+	return restart, nil
+}
+
+func (a *app) ListProfiles() ([]string, error) { return config.ListProfiles(a.appDir) }
+func (a *app) GetProfile(name string) (config.Config, error) {
+	return config.LoadProfile(a.appDir, name)
+}
+func (a *app) SaveProfile(name string, c config.Config) error {
+	return config.SaveProfile(a.appDir, name, c)
+}
+
+// ActivateProfile übernimmt ein Profil als aktive Config und verbindet den Reader neu.
+func (a *app) ActivateProfile(name string) ([]string, error) {
+	p, err := config.LoadProfile(a.appDir, name)
+	if err != nil {
+		return nil, err
+	}
+	cur := a.GetConfig()
+	restart, err := a.UpdateConfig(p)
+	if err != nil {
+		return nil, err
+	}
+	// UpdateConfig startet den Reader nur bei geändertem Port/Baud/Protokoll neu
+	if p.DevicePort == cur.DevicePort && p.Baud == cur.Baud && p.Protocol == cur.Protocol {
+		if err := a.mgr.SetPort(a.readerPort(p.DevicePort), int(p.Baud)); err != nil {
+			return nil, err
+		}
+	}
+	return restart, nil
+}
+
+// reloadConfig übernimmt eine extern geänderte config.json wie ein POST /api/config.
+func (a *app) reloadConfig(next config.Config) {
+	restart, err := a.UpdateConfig(next)
+	if err != nil {
+		slog.Warn("apply reloaded config", "err", err)
+		return
+	}
+	slog.Info("config reloaded from disk")
+	if len(restart) > 0 {
+		slog.Warn("config changes ignored until restart", "fields", restart)
+	}
+}
 
 func (a *app) saveConfig() {
 	if a.appDir == "" {
@@ -72,19 +471,26 @@ func (a *app) saveConfig() {
 	cfg := a.cfg
 	a.cfgMu.Unlock()
 	if err := config.Save(a.appDir, cfg); err != nil {
-		log.Printf("warn: save config: %v", err)
+		slog.Warn("save config", "err", err)
 	}
 }
 
 func main() {
 	port := flag.String("port", defaultPort(), "serial port for HP-90EPC (e.g. /dev/ttyUSB0 or COM3)")
-	baud := flag.Int("baud", 2400, "serial baud rate")
+	baud := flag.String("baud", "2400", "serial baud rate: 600, 1200, 2400, 4800 or 9600 (also \"9600 baud\", \"4.8k\")")
 	httpAddr := flag.String("http", ":8080", "HTTP listen address")
 	logDir := flag.String("logdir", "logs", "directory for CSV log files")
-	intervalMs := flag.Int("log-interval-ms", 1000, "logging interval in milliseconds")
+	intervalMs := flag.Int("log-interval-ms", 1000, "logging interval in milliseconds (0 = every frame)")
 	appdirFlag := flag.String("appdir", "", "custom app dir for config/logs")
 	portable := flag.Bool("portable", false, "store config/logs next to the binary")
 	noBrowser := flag.Bool("no-browser", false, "do not auto-open browser")
+	openURL := flag.String("open-url", "", "URL to open in the browser instead of the one derived from -http (e.g. behind a reverse proxy)")
+	autoPort := flag.Bool("auto-port", false, "probe available serial ports and use the first one sending frames")
+	showVersion := flag.Bool("version", false, "print version and build info, then exit")
+	watchConfig := flag.Bool("watch-config", false, "reload config.json when it is edited externally")
+	logLevelFlag := flag.String("log-level", "info", "diagnostic log level: debug, info, warn, error")
+	sim := flag.Bool("sim", false, "use a simulated meter instead of the serial port (demo/UI development)")
+	simHz := flag.Float64("sim-hz", 2, "measurements per second of the simulated meter")
 
 	setFlags := map[string]bool{}
 	flag.Parse()
@@ -92,22 +498,45 @@ func main() {
 		setFlags[f.Name] = true
 	})
 
+	if *showVersion {
+		fmt.Println(buildinfo.Get())
+		return
+	}
+
+	slog.SetDefault(slog.New(slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{Level: &logLevel})))
+	if setFlags["log-level"] {
+		// schon vor dem Laden der Config, damit deren Warnungen gefiltert werden
+		if err := setLogLevel(*logLevelFlag); err != nil {
+			slog.Warn("invalid -log-level", "err", err)
+		}
+	}
+
 	appDir, err := config.ResolveAppDir(*appdirFlag, *portable)
 	if err != nil {
-		log.Fatalf("resolve app dir: %v", err)
+		slog.Error("resolve app dir", "err", err)
+		os.Exit(1)
 	}
 
+	// Vorrang: Flags > Umgebung (HP90EPC_*) > config.json > Defaults
 	cfg, err := config.Load(appDir)
 	if err != nil {
-		log.Printf("warn: load config: %v (using defaults)", err)
-		cfg = config.Default()
+		// Load liefert dann Defaults inkl. Umgebungs-Overrides
+		slog.Warn("load config, using defaults", "err", err)
 	}
 
 	if setFlags["port"] {
 		cfg.DevicePort = *port
 	}
 	if setFlags["baud"] {
-		cfg.Baud = *baud
+		n, err := config.ParseBaud(*baud)
+		if err == nil {
+			err = config.CheckBaud(n)
+		}
+		if err != nil {
+			slog.Warn("invalid -baud, keeping configured rate", "err", err, "baud", cfg.Baud)
+		} else {
+			cfg.Baud = config.Baud(n)
+		}
 	}
 	if setFlags["http"] {
 		cfg.HTTPAddr = *httpAddr
@@ -118,60 +547,221 @@ func main() {
 	if setFlags["log-interval-ms"] {
 		cfg.LogIntervalMs = *intervalMs
 	}
+	if setFlags["open-url"] {
+		cfg.OpenURL = *openURL
+	}
+	if setFlags["log-level"] {
+		cfg.LogLevel = *logLevelFlag
+	}
+	if err := setLogLevel(cfg.LogLevel); err != nil {
+		slog.Warn("invalid log_level, using info", "err", err)
+		cfg.LogLevel = "info"
+		_ = setLogLevel(cfg.LogLevel)
+	}
 
-	// persist merged config
-	if err := config.Save(appDir, cfg); err != nil {
-		log.Printf("warn: save config: %v", err)
+	proto, err := protocolFor(cfg)
+	if err != nil {
+		slog.Warn("invalid protocol settings", "err", err, "using", reader.DefaultProtocol)
+		proto = reader.HP90EPC{}
 	}
 
-	resolvedLogDir := cfg.LogDir
-	if !filepath.IsAbs(resolvedLogDir) {
-		wd, _ := os.Getwd()
-		appDirLog := filepath.Join(appDir, resolvedLogDir)
-		cwdLog := filepath.Join(wd, resolvedLogDir)
-		switch {
-		case pathExists(cwdLog):
-			resolvedLogDir = cwdLog
-		case pathExists(appDirLog):
-			resolvedLogDir = appDirLog
-		default:
-			resolvedLogDir = appDirLog
+	if *autoPort && !*sim {
+		p, err := reader.AutoDetect(proto, int(cfg.Baud), 2*time.Second)
+		if err != nil {
+			slog.Warn("auto-port: no device found", "err", err, "using", cfg.DevicePort)
+		} else {
+			slog.Info("auto-port: found device", "port", p)
+			cfg.DevicePort = p
 		}
 	}
 
-	latest := &model.LatestBuffer{}
-	logger := logging.NewLogger(resolvedLogDir, time.Duration(cfg.LogIntervalMs)*time.Millisecond)
-	mgr := reader.NewManager(latest, logger, 3*time.Second)
+	// persist merged config
+	if err := config.Save(appDir, cfg); err != nil {
+		slog.Warn("save config", "err", err)
+	}
+
+	logDirAbs := resolveLogDir(appDir, cfg.LogDir)
+	primary := newDevice(cfg, config.DeviceID(cfg.DevicePort), logDirAbs, proto)
+	if *simHz > 0 {
+		primary.mgr.SetSimInterval(time.Duration(float64(time.Second) / *simHz))
+	}
 
 	// Reader starten (nicht fatal, wenn Multi nicht da ist)
-	_ = mgr.Start(cfg.DevicePort, cfg.Baud)
+	// -sim ersetzt nur den gestarteten Port, config.json behält den echten
+	startPort := cfg.DevicePort
+	if *sim {
+		startPort = reader.SimPort
+	}
+	_ = primary.mgr.Start(startPort, int(cfg.Baud))
+
+	extra := map[string]*device{}
+	for _, dc := range cfg.Devices {
+		id := config.DeviceID(dc.Port)
+		baud := dc.Baud
+		if baud == 0 {
+			baud = cfg.Baud
+		}
+		d := newDevice(cfg, id, extraLogDir(logDirAbs, id), proto)
+		_ = d.mgr.Start(dc.Port, int(baud))
+		extra[id] = d
+	}
 
 	app := &app{
-		latest: latest,
-		mgr:    mgr,
-		logger: logger,
+		device: primary,
+		extra:  extra,
 		cfg:    cfg,
 		appDir: appDir,
+		sim:    *sim,
+	}
+	for _, d := range app.all() {
+		d.latest.Subscribe(func(*model.Measurement) { app.pushWide() })
 	}
 
+	if cfg.LogAutoStart {
+		app.autoStartLogging()
+	}
+
+	watchCtx, stopWatch := context.WithCancel(context.Background())
+	defer stopWatch()
+	if *watchConfig {
+		go config.Watch(watchCtx, appDir, 2*time.Second, app.reloadConfig)
+	}
+
+	srvOpts := server.Options{
+		AuthToken:        cfg.AuthToken,
+		BasicAuthUser:    cfg.BasicAuthUser,
+		BasicAuthPass:    cfg.BasicAuthPass,
+		AuthProtectReads: cfg.AuthProtectReads,
+		CORSOrigins:      cfg.CORSOrigins,
+		TLSCertFile:      cfg.TLSCertFile,
+		TLSKeyFile:       cfg.TLSKeyFile,
+		TLSSelfSigned:    cfg.TLSSelfSigned,
+		ReadTimeout:      time.Duration(cfg.HTTPReadTimeoutMs) * time.Millisecond,
+		WriteTimeout:     time.Duration(cfg.HTTPWriteTimeoutMs) * time.Millisecond,
+		IdleTimeout:      time.Duration(cfg.HTTPIdleTimeoutMs) * time.Millisecond,
+		MaxBodyBytes:     cfg.HTTPMaxBodyBytes,
+		BasePath:         cfg.BasePath,
+	}
+	srv := server.New(cfg.HTTPAddr, app, srvOpts)
 	go func() {
-		if err := server.Start(cfg.HTTPAddr, app); err != nil {
-			log.Fatalf("http server: %v", err)
+		if err := srv.Start(); err != nil {
+			slog.Error("http server", "err", err)
+			os.Exit(1)
 		}
 	}()
 
-	if !*noBrowser {
-		go func() {
-			time.Sleep(600 * time.Millisecond)
-			url := urlFromAddr(*httpAddr)
-			_ = openBrowser(url)
-		}()
+	// Unix-Socket: kein Browser, erreichbar nur über den Reverse-Proxy – außer open_url nennt dessen Adresse
+	_, unix := server.UnixSocketPath(cfg.HTTPAddr)
+	if !*noBrowser && (!unix || cfg.OpenURL != "") {
+		if reason, ok := headless(); ok {
+			slog.Info("not opening browser", "reason", reason)
+		} else {
+			go func() {
+				time.Sleep(600 * time.Millisecond)
+				url := cfg.OpenURL
+				if url == "" {
+					url = urlFromAddr(cfg.HTTPAddr, srvOpts.TLS(), cfg.BasePath)
+				}
+				_ = openBrowser(url)
+			}()
+		}
+	}
+
+	slog.Info("HP-90EPC started", "version", buildinfo.Version, "http", cfg.HTTPAddr, "device", cfg.DevicePort, "baud", cfg.Baud, "appdir", appDir, "logdir", logDirAbs)
+
+	// bis SIGINT/SIGTERM laufen, dann Port freigeben, CSV flushen, HTTP beenden
+	sig := make(chan os.Signal, 1)
+	signal.Notify(sig, os.Interrupt, syscall.SIGTERM)
+	s := <-sig
+	slog.Info("shutting down", "signal", s)
+
+	// nach Rotation steht in der Config noch die erste Datei der Session
+	if st := app.logger.Status(); st.Active {
+		app.setActiveLogFile(st.File)
 	}
 
-	log.Printf("HP-90EPC started. HTTP=%s Device=%s@%d AppDir=%s", cfg.HTTPAddr, cfg.DevicePort, cfg.Baud, appDir)
+	for _, d := range app.all() {
+		d.mgr.Stop()
+		if err := d.logger.Stop(); err != nil {
+			slog.Warn("stop logging", "err", err)
+		}
+	}
+	// gerade geschlossene Dateien fertig komprimieren, sonst bleiben .tmp-Reste liegen
+	for _, d := range app.all() {
+		d.logger.Wait()
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := srv.Shutdown(ctx); err != nil {
+		slog.Warn("http shutdown", "err", err)
+	}
+}
+
+// logLevel steuert den Default-slog-Handler; zur Laufzeit über /api/config änderbar.
+var logLevel slog.LevelVar
+
+func setLogLevel(s string) error {
+	var l slog.Level
+	if err := l.UnmarshalText([]byte(s)); err != nil {
+		return err
+	}
+	logLevel.Set(l)
+	return nil
+}
+
+// protocolFor liefert das Protokoll aus der Config, beim HP-90EPC mit ggf. umbelegten Decode-Bits.
+func protocolFor(c config.Config) (reader.Protocol, error) {
+	proto, err := reader.LookupProtocol(c.Protocol)
+	if err != nil || c.Decode == nil {
+		return proto, err
+	}
+	if _, ok := proto.(reader.HP90EPC); !ok {
+		return proto, nil
+	}
+	opts := reader.DefaultDecodeOptions()
+	d := c.Decode
+	if len(d.DecimalPoints) == 3 {
+		for i, r := range d.DecimalPoints {
+			opts.DecimalPoints[i] = reader.BitRef{Byte: r.Byte, Bit: r.Bit}
+		}
+	}
+	for _, o := range []struct {
+		src *config.BitRef
+		dst *reader.BitRef
+	}{
+		{d.Nano, &opts.Nano},
+		{d.Micro, &opts.Micro},
+		{d.Milli, &opts.Milli},
+		{d.Kilo, &opts.Kilo},
+		{d.Mega, &opts.Mega},
+	} {
+		if o.src != nil {
+			*o.dst = reader.BitRef{Byte: o.src.Byte, Bit: o.src.Bit}
+		}
+	}
+	if err := opts.Validate(proto.FrameLen()); err != nil {
+		return nil, err
+	}
+	return reader.HP90EPC{Options: &opts}, nil
+}
 
-	// block forever
-	select {}
+// resolveLogDir: relative Log-Verzeichnisse bevorzugt relativ zum Arbeitsverzeichnis,
+// wenn es dort schon existiert, sonst relativ zum App-Verzeichnis.
+func resolveLogDir(appDir, dir string) string {
+	if !filepath.IsAbs(dir) {
+		wd, _ := os.Getwd()
+		cwdLog := filepath.Join(wd, dir)
+		if pathExists(cwdLog) {
+			dir = cwdLog
+		} else {
+			dir = filepath.Join(appDir, dir)
+		}
+	}
+	if abs, err := filepath.Abs(dir); err == nil {
+		dir = abs
+	}
+	return dir
 }
 
 func defaultPort() string {
@@ -186,21 +776,57 @@ func defaultPort() string {
 	}
 }
 
-func urlFromAddr(addr string) string {
-	a := strings.TrimSpace(addr)
-	if a == "" {
-		return "http://localhost:8080/"
-	}
-	if strings.HasPrefix(a, ":") {
-		return "http://localhost" + a + "/"
+// urlFromAddr: Browser-URL zur Listen-Adresse inkl. Schema und BasePath. Wildcard-Binds
+// (":8080", "0.0.0.0:8080", "[::]:8080") sind keine aufrufbaren Hosts und werden zu localhost.
+func urlFromAddr(addr string, tls bool, basePath string) string {
+	scheme := "http://"
+	if tls {
+		scheme = "https://"
 	}
+	a := strings.TrimSpace(addr)
 	if strings.HasPrefix(a, "http://") || strings.HasPrefix(a, "https://") {
 		if strings.HasSuffix(a, "/") {
 			return a
 		}
 		return a + "/"
 	}
-	return "http://" + a + "/"
+	if a == "" {
+		a = ":8080"
+	}
+	host, port, err := net.SplitHostPort(a)
+	if err != nil {
+		// ohne Port, z.B. "myhost"
+		host, port = a, ""
+	}
+	switch host {
+	case "", "0.0.0.0", "::", "[::]":
+		host = "localhost"
+	}
+	hostPort := host
+	if port != "" {
+		hostPort = net.JoinHostPort(host, port)
+	}
+	url := scheme + hostPort + "/"
+	if base := server.CleanBasePath(basePath); base != "" {
+		url += strings.TrimPrefix(base, "/") + "/"
+	}
+	return url
+}
+
+// headless erkennt Umgebungen ohne Desktop (SSH, Server ohne X11/Wayland), in denen
+// das Öffnen des Browsers nur fehlschlagen oder verwaiste Prozesse erzeugen würde.
+func headless() (reason string, ok bool) {
+	if os.Getenv("SSH_CONNECTION") != "" || os.Getenv("SSH_TTY") != "" {
+		return "ssh session", true
+	}
+	switch runtime.GOOS {
+	case "windows", "darwin":
+		return "", false
+	}
+	if os.Getenv("DISPLAY") == "" && os.Getenv("WAYLAND_DISPLAY") == "" {
+		return "no DISPLAY or WAYLAND_DISPLAY", true
+	}
+	return "", false
 }
 
 func openBrowser(url string) error {
@@ -219,6 +845,13 @@ func openBrowser(url string) error {
 	return cmd.Start()
 }
 
+func firstRune(s string) rune {
+	for _, r := range s {
+		return r
+	}
+	return 0
+}
+
 func pathExists(p string) bool {
 	_, err := os.Stat(p)
 	return err == nil
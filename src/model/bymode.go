@@ -0,0 +1,96 @@
+package model
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// ByMode merkt sich die letzte Messung je Einheit+Modus, z.B. "V DC" oder "Ohm",
+// damit nach einem Bereichswechsel der zuletzt gemessene Widerstand usw. abrufbar bleibt.
+type ByMode struct {
+	mu   sync.RWMutex
+	last map[string]*Measurement
+}
+
+// ModeKey: Schlüssel aus Einheit und Modus, leerer Modus entfällt.
+func ModeKey(unit, mode string) string {
+	return strings.TrimSpace(unit + " " + mode)
+}
+
+// Add übernimmt m unter ModeKey; Messungen ohne Einheit (z.B. "????") werden ignoriert.
+func (b *ByMode) Add(m *Measurement) {
+	if m == nil || m.Unit == "" {
+		return
+	}
+	key := ModeKey(m.Unit, m.Mode)
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.last == nil {
+		b.last = map[string]*Measurement{}
+	}
+	b.last[key] = m
+}
+
+// All liefert Kopien aller gemerkten Messungen.
+func (b *ByMode) All() map[string]*Measurement {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	out := make(map[string]*Measurement, len(b.last))
+	for k, m := range b.last {
+		out[k] = m.Clone()
+	}
+	return out
+}
+
+// ModeCounts zählt die Messungen je Einheit+Modus seit dem letzten Reset, z.B. um zu sehen,
+// dass eine Sitzung überwiegend Hz statt V erfasst hat.
+type ModeCounts struct {
+	mu    sync.Mutex
+	n     map[string]int64
+	since time.Time
+}
+
+// ModeStats: Zählerstand für /api/stats/modes; Messungen ohne Einheit fehlen wie bei ByMode.
+type ModeStats struct {
+	Since time.Time        `json:"since"`
+	Total int64            `json:"total"`
+	Modes map[string]int64 `json:"modes"`
+}
+
+// Add zählt m unter ModeKey; als Subscriber am LatestBuffer.
+func (c *ModeCounts) Add(m *Measurement) {
+	if m == nil || m.Unit == "" {
+		return
+	}
+	key := ModeKey(m.Unit, m.Mode)
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if c.n == nil {
+		c.n = map[string]int64{}
+	}
+	if c.since.IsZero() {
+		c.since = time.Now()
+	}
+	c.n[key]++
+}
+
+// Get liefert eine Kopie der Zähler.
+func (c *ModeCounts) Get() ModeStats {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	st := ModeStats{Since: c.since, Modes: make(map[string]int64, len(c.n))}
+	for k, v := range c.n {
+		st.Modes[k] = v
+		st.Total += v
+	}
+	return st
+}
+
+// Reset nullt die Zähler; since beginnt mit jetzt.
+func (c *ModeCounts) Reset() {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.n = nil
+	c.since = time.Now()
+}
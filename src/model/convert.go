@@ -0,0 +1,63 @@
+package model
+
+import (
+	"errors"
+	"strings"
+)
+
+var ErrNoConversion = errors.New("no conversion defined for this unit")
+
+// SI-Präfixe, wie sie decodeFrame im Unit-String verwendet ("u" als ASCII-Alias für µ)
+var siPrefixes = map[string]float64{
+	"":  1,
+	"n": 1e-9,
+	"µ": 1e-6,
+	"u": 1e-6,
+	"m": 1e-3,
+	"k": 1e3,
+	"M": 1e6,
+}
+
+var siBaseUnits = []string{"Ohm", "Hz", "V", "A", "F"}
+
+// splitSI zerlegt z.B. "kOhm" in ("k", "Ohm").
+func splitSI(unit string) (prefix, base string, ok bool) {
+	for _, b := range siBaseUnits {
+		if p, found := strings.CutSuffix(unit, b); found {
+			if _, known := siPrefixes[p]; known {
+				return p, b, true
+			}
+		}
+	}
+	return "", "", false
+}
+
+// Convert rechnet einen Messwert in die Zieleinheit um.
+// value ist wie Measurement.Value bereits in der Basiseinheit (V, A, Ohm, …), unit ist Measurement.Unit.
+// Unterstützt: Temperatur °C ↔ °F/K und SI-Präfix-Skalierung innerhalb derselben Basiseinheit.
+// Beide Einheiten dürfen in jeder Schreibweise von CanonicalUnit stehen ("kΩ", "uA", "degC").
+func Convert(value float64, unit, target string) (float64, error) {
+	unit, target = CanonicalUnit(unit), CanonicalUnit(target)
+	switch unit {
+	case "°C":
+		switch target {
+		case "°C":
+			return value, nil
+		case "°F":
+			return value*9/5 + 32, nil
+		case "K":
+			return value + 273.15, nil
+		}
+		return 0, ErrNoConversion
+	}
+
+	_, base, ok := splitSI(unit)
+	if !ok {
+		return 0, ErrNoConversion
+	}
+	tp, tb, ok := splitSI(target)
+	if !ok || tb != base {
+		return 0, ErrNoConversion
+	}
+	return value / siPrefixes[tp], nil
+}
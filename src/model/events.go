@@ -0,0 +1,104 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// ModeEvent: Wechsel der Messfunktion, z.B. Drehschalter von "V DC" auf "Ohm".
+// From ist beim ersten Wert nach Start bzw. Reset leer.
+type ModeEvent struct {
+	Time time.Time `json:"time"`
+	From string    `json:"from"`
+	To   string    `json:"to"`
+}
+
+// EventLog: Ringpuffer der letzten Funktionswechsel. Bereichswechsel (mV → V, kOhm → MOhm)
+// zählen nicht, nur Basiseinheit und AC/DC.
+type EventLog struct {
+	mu      sync.Mutex
+	buf     []ModeEvent
+	next    int
+	full    bool
+	current string
+	onEvent func(ModeEvent)
+}
+
+func NewEventLog(size int) *EventLog {
+	if size <= 0 {
+		size = 200
+	}
+	return &EventLog{buf: make([]ModeEvent, size)}
+}
+
+// OnEvent meldet fn für jedes neue Ereignis an (Aufruf im Reader-Goroutine, z.B. zum Mitschreiben).
+func (e *EventLog) OnEvent(fn func(ModeEvent)) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	e.onEvent = fn
+}
+
+// Add vergleicht die Funktion von m mit der vorigen; Messungen ohne Einheit werden ignoriert.
+func (e *EventLog) Add(m *Measurement) {
+	if m == nil || m.Unit == "" {
+		return
+	}
+	key := functionKey(m)
+	e.mu.Lock()
+	if key == e.current {
+		e.mu.Unlock()
+		return
+	}
+	ev := ModeEvent{Time: m.Timestamp, From: e.current, To: key}
+	if ev.Time.IsZero() {
+		ev.Time = time.Now()
+	}
+	e.current = key
+	e.buf[e.next] = ev
+	e.next = (e.next + 1) % len(e.buf)
+	if e.next == 0 {
+		e.full = true
+	}
+	fn := e.onEvent
+	e.mu.Unlock()
+
+	if fn != nil {
+		fn(ev)
+	}
+}
+
+// Last liefert bis zu n Ereignisse, älteste zuerst. n <= 0 = alle.
+func (e *EventLog) Last(n int) []ModeEvent {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	count := e.next
+	if e.full {
+		count = len(e.buf)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+	out := make([]ModeEvent, n)
+	start := (e.next - n + len(e.buf)) % len(e.buf)
+	for i := range out {
+		out[i] = e.buf[(start+i)%len(e.buf)]
+	}
+	return out
+}
+
+// Reset leert den Puffer; der nächste Wert wird wieder mit leerem From eingetragen.
+func (e *EventLog) Reset() {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+	clear(e.buf)
+	e.next, e.full, e.current = 0, false, ""
+}
+
+// functionKey: ModeKey ohne SI-Präfix, damit Bereichswechsel keine Ereignisse auslösen.
+func functionKey(m *Measurement) string {
+	unit := CanonicalUnit(m.Unit)
+	if _, base, ok := splitSI(unit); ok {
+		unit = base
+	}
+	return ModeKey(unit, m.Mode)
+}
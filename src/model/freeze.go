@@ -0,0 +1,76 @@
+package model
+
+import (
+	"errors"
+	"sync"
+	"time"
+)
+
+// ErrNoFreezeValue: zum Einfrieren liegt noch keine Messung vor.
+var ErrNoFreezeValue = errors.New("no measurement to freeze")
+
+// FreezeState: serverseitig eingefrorene Anzeige, unabhängig von der HOLD-Taste des Geräts.
+type FreezeState struct {
+	Active bool       `json:"active"`
+	Since  *time.Time `json:"since,omitempty"`
+}
+
+// Freeze hält die Messung vom Zeitpunkt des Einfrierens. Der Reader läuft weiter,
+// nur die Anzeige (GetLatest, GetLatestSeq, Live-Stream) bleibt stehen.
+type Freeze struct {
+	mu    sync.Mutex
+	m     *Measurement
+	seq   uint64
+	since time.Time
+}
+
+// Set friert m mit seiner Sequenznummer aus LatestBuffer.GetSeq ein; ein bereits eingefrorener Wert wird ersetzt.
+func (f *Freeze) Set(m *Measurement, seq uint64) (FreezeState, error) {
+	if m == nil {
+		return FreezeState{}, ErrNoFreezeValue
+	}
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.m = m.Clone()
+	f.seq = seq
+	f.since = time.Now()
+	return f.stateLocked(), nil
+}
+
+// Clear gibt die Anzeige wieder frei.
+func (f *Freeze) Clear() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.m = nil
+	f.seq = 0
+	f.since = time.Time{}
+}
+
+// Measurement liefert eine Kopie der eingefrorenen Messung, nil wenn nicht eingefroren.
+func (f *Freeze) Measurement() *Measurement {
+	m, _ := f.MeasurementSeq()
+	return m
+}
+
+// MeasurementSeq wie Measurement, dazu die Sequenznummer beim Einfrieren: sie bleibt stehen,
+// solange eingefroren ist, Long-Poll-Clients warten also bis Unfreeze.
+func (f *Freeze) MeasurementSeq() (*Measurement, uint64) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.m.Clone(), f.seq
+}
+
+// Get liefert den aktuellen Stand.
+func (f *Freeze) Get() FreezeState {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	return f.stateLocked()
+}
+
+func (f *Freeze) stateLocked() FreezeState {
+	if f.m == nil {
+		return FreezeState{}
+	}
+	since := f.since
+	return FreezeState{Active: true, Since: &since}
+}
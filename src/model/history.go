@@ -0,0 +1,71 @@
+package model
+
+import (
+	"sync"
+	"time"
+)
+
+// Sample: ein Punkt im Verlauf. Unit/Mode pro Punkt, damit ein Chart bei Bereichswechseln segmentieren kann.
+type Sample struct {
+	T     time.Time `json:"t"`
+	Value *float64  `json:"value"`
+	Unit  string    `json:"unit"`
+	Mode  string    `json:"mode"`
+}
+
+// History: threadsicherer Ringpuffer der letzten N Messungen, älteste fallen automatisch raus.
+type History struct {
+	mu   sync.RWMutex
+	buf  []Sample
+	next int
+	full bool
+}
+
+func NewHistory(size int) *History {
+	if size <= 0 {
+		size = 600
+	}
+	return &History{buf: make([]Sample, size)}
+}
+
+func (h *History) Add(m *Measurement) {
+	if m == nil {
+		return
+	}
+	s := Sample{T: m.Timestamp, Unit: m.Unit, Mode: m.Mode}
+	if s.T.IsZero() {
+		s.T = time.Now()
+	}
+	if m.Value != nil {
+		v := *m.Value
+		s.Value = &v
+	}
+
+	h.mu.Lock()
+	defer h.mu.Unlock()
+	h.buf[h.next] = s
+	h.next = (h.next + 1) % len(h.buf)
+	if h.next == 0 {
+		h.full = true
+	}
+}
+
+// Last liefert bis zu n Samples, älteste zuerst. n <= 0 = alle.
+func (h *History) Last(n int) []Sample {
+	h.mu.RLock()
+	defer h.mu.RUnlock()
+
+	count := h.next
+	if h.full {
+		count = len(h.buf)
+	}
+	if n <= 0 || n > count {
+		n = count
+	}
+	out := make([]Sample, n)
+	start := (h.next - n + len(h.buf)) % len(h.buf)
+	for i := 0; i < n; i++ {
+		out[i] = h.buf[(start+i)%len(h.buf)]
+	}
+	return out
+}
@@ -1,34 +1,151 @@
 package model
 
-import "sync"
+import (
+	"sync"
+	"time"
+)
 
 type Measurement struct {
-	Value    *float64 `json:"value"`
-	ValueStr string   `json:"value_str"`
-	Unit     string   `json:"unit"`
-	Mode     string   `json:"mode"`
-	Auto     bool     `json:"auto"`
-	Hold     bool     `json:"hold"`
-	Rel      bool     `json:"rel"`
-	LowBatt  bool     `json:"low_batt"`
-	RawHex   string   `json:"raw"`
+	// Zeitpunkt, an dem der Frame dekodiert wurde (RFC3339 im JSON)
+	Timestamp time.Time `json:"t"`
+
+	Value      *float64 `json:"value"`
+	ValueStr   string   `json:"value_str"`
+	Unit       string   `json:"unit"`
+	Mode       string   `json:"mode"`
+	Auto       bool     `json:"auto"`
+	Hold       bool     `json:"hold"`
+	Rel        bool     `json:"rel"`
+	LowBatt    bool     `json:"low_batt"`
+	Diode      bool     `json:"diode"`
+	Continuity bool     `json:"continuity"`
+	Beep       bool     `json:"beep"`
+	Overload   bool     `json:"overload"`
+	RawHex     string   `json:"raw"`
+
+	Annunciators Annunciators `json:"annunciators"`
+
+	// Segments: Rohbild der 7-Segment-Anzeige; nur auf Anfrage (?segments=1) im JSON
+	Segments Segments `json:"-"`
+	// Diag: nur bei nicht lesbarer Anzeige ("????"/"OL") gesetzt; nur auf Anfrage (?debug=1) im JSON
+	Diag *DecodeDiag `json:"-"`
+}
+
+// DecodeDiag zeigt, welche Stellen keinem bekannten Ziffernmuster entsprachen (für Fehlerberichte).
+type DecodeDiag struct {
+	Digits [4]DigitDiag `json:"digits"`
+	// Failed: Indizes (0 = links) der nicht erkannten Stellen
+	Failed []int `json:"failed"`
+}
+
+// DigitDiag: eine Stelle, wie sie aus dem Frame zusammengesetzt wurde.
+type DigitDiag struct {
+	// Byte: Segmentbyte der Stelle als Hex, z.B. "7D"
+	Byte string `json:"byte"`
+	// Digit: erkannte Ziffer, -1 = unbekanntes Muster
+	Digit int  `json:"digit"`
+	Known bool `json:"known"`
+}
+
+// Segments: Anzeige-Inhalt unabhängig davon, ob er sich als Zahl lesen lässt (auch "OL", "----").
+type Segments struct {
+	// Digits: je Stelle eine Maske, Bit 0..6 = Segment a..g (a oben, dann im Uhrzeigersinn, g Mitte)
+	Digits [4]uint8 `json:"digits"`
+	// Points[i]: Dezimalpunkt rechts von Stelle i
+	Points [4]bool `json:"points"`
+	Minus  bool    `json:"minus"`
+}
+
+// Clone liefert eine tiefe Kopie (inkl. Value), die der Aufrufer frei ändern darf.
+func (m *Measurement) Clone() *Measurement {
+	if m == nil {
+		return nil
+	}
+	c := *m
+	if m.Value != nil {
+		v := *m.Value
+		c.Value = &v
+	}
+	if m.Diag != nil {
+		d := *m.Diag
+		d.Failed = append([]int(nil), m.Diag.Failed...)
+		c.Diag = &d
+	}
+	return &c
+}
+
+// Annunciators: alle dekodierten LCD-Symbole, damit die UI generisch darüber iterieren kann.
+// Die Top-Level-Booleans in Measurement werden daraus abgeleitet.
+type Annunciators struct {
+	AC      bool `json:"ac"`
+	DC      bool `json:"dc"`
+	Auto    bool `json:"auto"`
+	Hold    bool `json:"hold"`
+	Rel     bool `json:"rel"`
+	LowBatt bool `json:"low_batt"`
+	Diode   bool `json:"diode"`
+	Beep    bool `json:"beep"`
 }
 
 // LatestBuffer: threadsicherer Puffer für die letzte Messung
 type LatestBuffer struct {
 	mu     sync.RWMutex
 	latest *Measurement
+	// seq zählt jedes Set, damit Clients "neuer als" abfragen können (Long-Polling)
+	seq uint64
+
+	subs   map[int]func(*Measurement)
+	nextID int
 }
 
+// Set übernimmt m; fehlt der Zeitstempel, wird der Set-Zeitpunkt eingetragen,
+// damit /api/live und der Verlauf dieselbe Zeit zeigen.
 func (b *LatestBuffer) Set(m *Measurement) {
+	if m != nil && m.Timestamp.IsZero() {
+		m.Timestamp = time.Now()
+	}
 	b.mu.Lock()
-	defer b.mu.Unlock()
 	b.latest = m
+	b.seq++
+	subs := make([]func(*Measurement), 0, len(b.subs))
+	for _, fn := range b.subs {
+		subs = append(subs, fn)
+	}
+	b.mu.Unlock()
+
+	for _, fn := range subs {
+		fn(m)
+	}
 }
 
+// Subscribe ruft fn bei jedem Set auf (im Reader-Goroutine, außerhalb des Locks, also kurz halten).
+// Die zurückgegebene Funktion meldet wieder ab.
+func (b *LatestBuffer) Subscribe(fn func(*Measurement)) (cancel func()) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+	if b.subs == nil {
+		b.subs = map[int]func(*Measurement){}
+	}
+	id := b.nextID
+	b.nextID++
+	b.subs[id] = fn
+	return func() {
+		b.mu.Lock()
+		defer b.mu.Unlock()
+		delete(b.subs, id)
+	}
+}
+
+// Get liefert eine Kopie der letzten Messung; Änderungen daran wirken nicht auf den Puffer zurück.
 func (b *LatestBuffer) Get() *Measurement {
 	b.mu.RLock()
 	defer b.mu.RUnlock()
-	return b.latest
+	return b.latest.Clone()
 }
 
+// GetSeq liefert wie Get die letzte Messung und dazu ihre Sequenznummer (0 = noch nie gesetzt).
+func (b *LatestBuffer) GetSeq() (*Measurement, uint64) {
+	b.mu.RLock()
+	defer b.mu.RUnlock()
+	return b.latest.Clone(), b.seq
+}
@@ -0,0 +1,122 @@
+package model
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestMeasurementClone(t *testing.T) {
+	v := 1.5
+	m := &Measurement{Value: &v, ValueStr: "1.500", Unit: "V", Diag: &DecodeDiag{Failed: []int{1}}}
+	c := m.Clone()
+	if !reflect.DeepEqual(c, m) {
+		t.Fatalf("clone = %+v, want equal to %+v", c, m)
+	}
+	*c.Value = 2
+	c.Diag.Failed[0] = 3
+	c.Diag.Digits[0].Byte = "7D"
+	c.Unit = "mV"
+	if *m.Value != 1.5 || m.Diag.Failed[0] != 1 || m.Diag.Digits[0].Byte != "" || m.Unit != "V" {
+		t.Errorf("changing the clone changed the original: %+v", m)
+	}
+	if (*Measurement)(nil).Clone() != nil {
+		t.Error("nil.Clone() != nil")
+	}
+}
+
+func TestLatestBufferGetIsolated(t *testing.T) {
+	var b LatestBuffer
+	if m, seq := b.GetSeq(); m != nil || seq != 0 {
+		t.Fatalf("empty buffer = %v, %d", m, seq)
+	}
+
+	v := 3.3
+	b.Set(&Measurement{Value: &v, Unit: "V"})
+	got := b.Get()
+	*got.Value = 0
+	got.Unit = "A"
+
+	again, seq := b.GetSeq()
+	if *again.Value != 3.3 || again.Unit != "V" {
+		t.Errorf("buffer changed through a returned copy: %+v", again)
+	}
+	if again == got || again.Value == got.Value {
+		t.Error("Get returned shared pointers")
+	}
+	if seq != 1 {
+		t.Errorf("seq = %d, want 1", seq)
+	}
+	if again.Timestamp.IsZero() {
+		t.Error("Set did not fill in the timestamp")
+	}
+}
+
+func TestLatestBufferSubscribe(t *testing.T) {
+	var b LatestBuffer
+	var first, second []string
+	cancel := b.Subscribe(func(m *Measurement) { first = append(first, m.ValueStr) })
+	b.Subscribe(func(m *Measurement) {
+		second = append(second, m.ValueStr)
+		// Subscriber laufen außerhalb des Locks: Get darf nicht blockieren
+		if got := b.Get(); got.ValueStr != m.ValueStr {
+			t.Errorf("Get in subscriber = %q, want %q", got.ValueStr, m.ValueStr)
+		}
+	})
+
+	ts := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	b.Set(&Measurement{ValueStr: "1", Timestamp: ts})
+	b.Set(&Measurement{ValueStr: "2"})
+	cancel()
+	cancel() // zweites Abmelden ist harmlos
+	b.Set(&Measurement{ValueStr: "3"})
+
+	if !reflect.DeepEqual(first, []string{"1", "2"}) {
+		t.Errorf("cancelled subscriber saw %q, want [1 2]", first)
+	}
+	if !reflect.DeepEqual(second, []string{"1", "2", "3"}) {
+		t.Errorf("subscriber saw %q, want [1 2 3]", second)
+	}
+	if _, seq := b.GetSeq(); seq != 3 {
+		t.Errorf("seq = %d, want 3", seq)
+	}
+	// ein gesetzter Zeitstempel bleibt erhalten
+	var seen time.Time
+	b.Subscribe(func(m *Measurement) { seen = m.Timestamp })
+	b.Set(&Measurement{Timestamp: ts})
+	if !seen.Equal(ts) {
+		t.Errorf("timestamp = %v, want %v", seen, ts)
+	}
+}
+
+func TestFreezeCopyOnRead(t *testing.T) {
+	var f Freeze
+	if _, err := f.Set(nil, 0); err != ErrNoFreezeValue {
+		t.Fatalf("Set(nil) err = %v, want ErrNoFreezeValue", err)
+	}
+
+	v := 1.5
+	m := &Measurement{Value: &v, ValueStr: "1.5"}
+	st, err := f.Set(m, 7)
+	if err != nil || !st.Active || st.Since == nil {
+		t.Fatalf("Set = %+v, %v", st, err)
+	}
+	// weder der Aufrufer von Set noch ein Leser verändern den eingefrorenen Wert
+	v = 9
+	got := f.Measurement()
+	*got.Value = 3
+	got.ValueStr = "3"
+
+	again, seq := f.MeasurementSeq()
+	if *again.Value != 1.5 || again.ValueStr != "1.5" || seq != 7 {
+		t.Errorf("frozen = %v %q seq %d, want 1.5 \"1.5\" seq 7", *again.Value, again.ValueStr, seq)
+	}
+	if again == got {
+		t.Error("Measurement returned the same pointer twice")
+	}
+
+	f.Clear()
+	if m, seq := f.MeasurementSeq(); m != nil || seq != 0 || f.Get().Active {
+		t.Errorf("after Clear: %v seq %d active %v", m, seq, f.Get().Active)
+	}
+}
@@ -0,0 +1,76 @@
+package model
+
+import "time"
+
+// DefaultRateWindow: Zeitfenster für RateOf, wenn keins angegeben ist.
+const DefaultRateWindow = 5 * time.Second
+
+// minRateSamples: darunter ist die Steigung zu verrauscht, um etwas zu bedeuten.
+const minRateSamples = 3
+
+// Rate: Änderung des Messwerts pro Sekunde über die letzten Samples gleicher Einheit und Modus.
+type Rate struct {
+	// PerSecond: nil bei zu wenigen Samples oder nicht-numerischem aktuellen Wert
+	PerSecond *float64 `json:"per_second"`
+	// Unit der Steigung in der Basiseinheit wie Measurement.Value, z.B. "V/s" auch im mV-Bereich
+	Unit     string `json:"unit"`
+	Mode     string `json:"mode"`
+	Samples  int    `json:"samples"`
+	WindowMs int64  `json:"window_ms"`
+}
+
+// RateOf berechnet die Steigung (lineare Regression) über die jüngsten Samples innerhalb window.
+// Das Fenster beginnt neu beim letzten Einheiten-/Moduswechsel oder nicht-numerischen Wert.
+// samples sind wie bei History.Last älteste zuerst.
+func RateOf(samples []Sample, window time.Duration) Rate {
+	if window <= 0 {
+		window = DefaultRateWindow
+	}
+	r := Rate{WindowMs: window.Milliseconds()}
+	if len(samples) == 0 {
+		return r
+	}
+	last := samples[len(samples)-1]
+	r.Mode = last.Mode
+	if last.Unit != "" {
+		r.Unit = BaseUnit(last.Unit) + "/s"
+	}
+	if last.Value == nil {
+		return r
+	}
+
+	from := last.T.Add(-window)
+	first := len(samples) - 1
+	for i := len(samples) - 2; i >= 0; i-- {
+		s := samples[i]
+		if s.Value == nil || s.Unit != last.Unit || s.Mode != last.Mode || s.T.Before(from) {
+			break
+		}
+		first = i
+	}
+	win := samples[first:]
+	r.Samples = len(win)
+	if len(win) < minRateSamples {
+		return r
+	}
+
+	// Regression über Sekunden relativ zum ersten Sample, damit die Werte klein bleiben
+	var sx, sy, sxx, sxy float64
+	for _, s := range win {
+		x := s.T.Sub(win[0].T).Seconds()
+		y := *s.Value
+		sx += x
+		sy += y
+		sxx += x * x
+		sxy += x * y
+	}
+	n := float64(len(win))
+	den := n*sxx - sx*sx
+	if den == 0 {
+		// alle Samples mit gleichem Zeitstempel
+		return r
+	}
+	slope := (n*sxy - sx*sy) / den
+	r.PerSecond = &slope
+	return r
+}
@@ -0,0 +1,64 @@
+package model
+
+import (
+	"math"
+	"testing"
+	"time"
+)
+
+func TestBaseUnit(t *testing.T) {
+	tests := map[string]string{
+		"kOhm": "Ohm",
+		"MOhm": "Ohm",
+		"kΩ":   "Ω",
+		"kohm": "ohm",
+		"mV":   "V",
+		"µA":   "A",
+		"uA":   "A",
+		"nF":   "F",
+		"kHz":  "Hz",
+		"V":    "V",
+		"°C":   "°C",
+		"%":    "%",
+		"":     "",
+	}
+	for in, want := range tests {
+		if got := BaseUnit(in); got != want {
+			t.Errorf("BaseUnit(%q) = %q, want %q", in, got, want)
+		}
+	}
+}
+
+// samplesOf: ein Sample pro Sekunde mit den Werten vs (Basiseinheit) und der Anzeigeeinheit unit.
+func samplesOf(unit string, vs ...float64) []Sample {
+	t0 := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	out := make([]Sample, len(vs))
+	for i, v := range vs {
+		v := v
+		out[i] = Sample{T: t0.Add(time.Duration(i) * time.Second), Value: &v, Unit: unit}
+	}
+	return out
+}
+
+func TestRateOfUnitMatchesValue(t *testing.T) {
+	// 4,7 kΩ steigen um 10 Ω/s: Value ist in Ohm, also muss auch die Steigung in Ohm/s stehen
+	r := RateOf(samplesOf("kOhm", 4700, 4710, 4720, 4730), time.Minute)
+	if r.PerSecond == nil || math.Abs(*r.PerSecond-10) > 1e-9 {
+		t.Fatalf("per_second = %v, want 10", r.PerSecond)
+	}
+	if r.Unit != "Ohm/s" {
+		t.Errorf("unit = %q, want Ohm/s", r.Unit)
+	}
+
+	r = RateOf(samplesOf("mV", 0.001, 0.002, 0.003), time.Minute)
+	if r.PerSecond == nil || math.Abs(*r.PerSecond-0.001) > 1e-12 || r.Unit != "V/s" {
+		t.Errorf("rate = %v %q, want 0.001 V/s", r.PerSecond, r.Unit)
+	}
+}
+
+func TestSmoothOfUnitMatchesValue(t *testing.T) {
+	s := SmoothOf(samplesOf("kOhm", 4700, 4710), time.Minute)
+	if s.Mean == nil || *s.Mean != 4705 || s.Unit != "Ohm" {
+		t.Errorf("smoothed = %v %q, want 4705 Ohm", s.Mean, s.Unit)
+	}
+}
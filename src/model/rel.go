@@ -0,0 +1,76 @@
+package model
+
+import (
+	"errors"
+	"sync"
+)
+
+// ErrNoRelValue: für den Nullpunkt fehlt eine numerische Messung (kein Frame, "OL", "????").
+var ErrNoRelValue = errors.New("no numeric measurement to use as baseline")
+
+// RelState: Software-REL – ein Nullpunkt, der von späteren Werten derselben Einheit abgezogen wird.
+// Unabhängig vom REL-Flag des Geräts (Measurement.Rel).
+type RelState struct {
+	Active bool `json:"active"`
+	// Baseline in der Basiseinheit wie Measurement.Value
+	Baseline *float64 `json:"baseline"`
+	Unit     string   `json:"unit,omitempty"`
+	Mode     string   `json:"mode,omitempty"`
+}
+
+// Apply liefert m.Value - Baseline; nil, wenn REL aus ist, m nicht numerisch ist oder die Einheit nicht passt.
+func (s RelState) Apply(m *Measurement) *float64 {
+	if !s.Active || m == nil || m.Value == nil || s.Baseline == nil {
+		return nil
+	}
+	if ModeKey(m.Unit, m.Mode) != ModeKey(s.Unit, s.Mode) {
+		return nil
+	}
+	v := *m.Value - *s.Baseline
+	return &v
+}
+
+// Rel hält den Software-Nullpunkt eines Geräts. Add (als Subscriber am LatestBuffer)
+// verwirft ihn, sobald Einheit oder Modus wechseln.
+type Rel struct {
+	mu    sync.Mutex
+	state RelState
+}
+
+// Set übernimmt m als Nullpunkt für dessen Einheit und Modus.
+func (r *Rel) Set(m *Measurement) (RelState, error) {
+	if m == nil || m.Value == nil || m.Unit == "" {
+		return RelState{}, ErrNoRelValue
+	}
+	v := *m.Value
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = RelState{Active: true, Baseline: &v, Unit: m.Unit, Mode: m.Mode}
+	return r.state, nil
+}
+
+// Clear schaltet REL aus.
+func (r *Rel) Clear() {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	r.state = RelState{}
+}
+
+// Get liefert den aktuellen Stand.
+func (r *Rel) Get() RelState {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	return r.state
+}
+
+// Add verwirft den Nullpunkt bei einem Einheiten-/Moduswechsel; Messungen ohne Einheit ("????") zählen nicht.
+func (r *Rel) Add(m *Measurement) {
+	if m == nil || m.Unit == "" {
+		return
+	}
+	r.mu.Lock()
+	defer r.mu.Unlock()
+	if r.state.Active && ModeKey(m.Unit, m.Mode) != ModeKey(r.state.Unit, r.state.Mode) {
+		r.state = RelState{}
+	}
+}
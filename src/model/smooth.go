@@ -0,0 +1,65 @@
+package model
+
+import (
+	"math"
+	"time"
+)
+
+// DefaultSmoothWindow: Zeitfenster für SmoothOf, wenn keins angegeben ist.
+const DefaultSmoothWindow = 2 * time.Second
+
+// Smoothed: gleitender Mittelwert für eine ruhige Anzeige (keine Min/Max-Statistik).
+type Smoothed struct {
+	// Mean/StdDev: nil ohne numerische Samples; StdDev erst ab zwei Samples.
+	// Unit ist die Basiseinheit wie bei Measurement.Value ("Ohm" auch im kOhm-Bereich).
+	Mean     *float64 `json:"mean"`
+	StdDev   *float64 `json:"stddev"`
+	Unit     string   `json:"unit"`
+	Mode     string   `json:"mode"`
+	Samples  int      `json:"samples"`
+	WindowMs int64    `json:"window_ms"`
+}
+
+// SmoothOf mittelt die jüngsten numerischen Samples innerhalb window.
+// Wie bei RateOf beginnt das Fenster neu beim letzten Einheiten-/Moduswechsel oder nicht-numerischen Wert;
+// samples sind älteste zuerst.
+func SmoothOf(samples []Sample, window time.Duration) Smoothed {
+	if window <= 0 {
+		window = DefaultSmoothWindow
+	}
+	r := Smoothed{WindowMs: window.Milliseconds()}
+	if len(samples) == 0 {
+		return r
+	}
+	last := samples[len(samples)-1]
+	r.Unit, r.Mode = BaseUnit(last.Unit), last.Mode
+	if last.Value == nil {
+		return r
+	}
+
+	from := last.T.Add(-window)
+	first := len(samples) - 1
+	for i := len(samples) - 2; i >= 0; i-- {
+		s := samples[i]
+		if s.Value == nil || s.Unit != last.Unit || s.Mode != last.Mode || s.T.Before(from) {
+			break
+		}
+		first = i
+	}
+	win := samples[first:]
+	r.Samples = len(win)
+
+	// Welford, damit große Offsets mit kleinem Rauschen (230 V ± 1 Digit) nicht auslöschen
+	var mean, m2 float64
+	for i, s := range win {
+		d := *s.Value - mean
+		mean += d / float64(i+1)
+		m2 += d * (*s.Value - mean)
+	}
+	r.Mean = &mean
+	if len(win) > 1 {
+		sd := math.Sqrt(m2 / float64(len(win)-1))
+		r.StdDev = &sd
+	}
+	return r
+}
@@ -0,0 +1,70 @@
+package model
+
+import "math"
+
+// Stats sammelt Messungen eines Zeitfensters: min/max/mean der numerischen Werte
+// sowie die häufigste Einheit und Betriebsart. Nicht threadsicher.
+type Stats struct {
+	Samples int // alle Messungen, auch ohne Zahlenwert (OL, ----)
+	Count   int // Messungen mit Zahlenwert
+	Min     float64
+	Max     float64
+	Sum     float64
+
+	units map[string]int
+	modes map[string]int
+}
+
+func (s *Stats) Add(m *Measurement) {
+	if m == nil {
+		return
+	}
+	if s.units == nil {
+		s.units = map[string]int{}
+		s.modes = map[string]int{}
+	}
+	s.Samples++
+	s.units[m.Unit]++
+	s.modes[m.Mode]++
+	if m.Value == nil || math.IsNaN(*m.Value) {
+		return
+	}
+	v := *m.Value
+	if s.Count == 0 || v < s.Min {
+		s.Min = v
+	}
+	if s.Count == 0 || v > s.Max {
+		s.Max = v
+	}
+	s.Sum += v
+	s.Count++
+}
+
+// Mean: Mittelwert der numerischen Werte, NaN ohne Werte.
+func (s *Stats) Mean() float64 {
+	if s.Count == 0 {
+		return math.NaN()
+	}
+	return s.Sum / float64(s.Count)
+}
+
+// Unit liefert die häufigste Einheit im Fenster.
+func (s *Stats) Unit() string { return dominant(s.units) }
+
+// Mode liefert die häufigste Betriebsart (AC/DC/…) im Fenster.
+func (s *Stats) Mode() string { return dominant(s.modes) }
+
+func (s *Stats) Reset() {
+	*s = Stats{}
+}
+
+func dominant(counts map[string]int) string {
+	best, bestN := "", 0
+	for k, n := range counts {
+		// bei Gleichstand deterministisch den kleineren Schlüssel nehmen
+		if n > bestN || (n == bestN && k < best) {
+			best, bestN = k, n
+		}
+	}
+	return best
+}
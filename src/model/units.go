@@ -0,0 +1,80 @@
+package model
+
+import "strings"
+
+// Schreibweisen für UnitStyle.Ohm
+const (
+	OhmWord   = "Ohm"
+	OhmSymbol = "Ω"
+	OhmLower  = "ohm"
+)
+
+// UnitStyle legt fest, wie Measurement.Unit geschrieben wird – für Live-JSON und Logs gleichermaßen.
+// Der Nullwert entspricht der bisherigen Ausgabe ("Ohm", "µ", "°C").
+type UnitStyle struct {
+	// Ohm: OhmWord (Default), OhmSymbol oder OhmLower
+	Ohm string
+	// ASCII: nur 7-Bit-Zeichen, "µ" → "u" und "°C" → "degC"; nicht mit OhmSymbol kombinierbar
+	ASCII bool
+}
+
+// IsDefault meldet, ob s die Einheiten unverändert lässt.
+func (s UnitStyle) IsDefault() bool {
+	return (s.Ohm == "" || s.Ohm == OhmWord) && !s.ASCII
+}
+
+// Render schreibt eine Einheit aus dem Decoder (z.B. "kOhm", "µA", "°C") im Stil s.
+func (s UnitStyle) Render(unit string) string {
+	if s.IsDefault() || unit == "" {
+		return unit
+	}
+	if p, ok := strings.CutSuffix(unit, OhmWord); ok && s.Ohm != "" {
+		unit = p + s.Ohm
+	}
+	if s.ASCII {
+		unit = strings.NewReplacer("µ", "u", "°C", "degC").Replace(unit)
+	}
+	return unit
+}
+
+// Apply schreibt m.Unit im Stil s um.
+func (s UnitStyle) Apply(m *Measurement) {
+	if m != nil {
+		m.Unit = s.Render(m.Unit)
+	}
+}
+
+// unitAliases: alle Schreibweisen, die CanonicalUnit auf die Decoder-Form zurückführt
+var unitAliases = strings.NewReplacer(
+	OhmSymbol, OhmWord,
+	"Ω", OhmWord, // Ohm-Zeichen, sieht aus wie Ω
+	OhmLower, OhmWord,
+	"μ", "µ", // griechisches My statt Mikro-Zeichen
+	"degC", "°C",
+	"degF", "°F",
+)
+
+// BaseUnit entfernt das SI-Präfix und behält die Schreibweise bei ("kΩ" → "Ω", "uA" → "A", "mV" → "V").
+// Passt zu Measurement.Value, das immer in der Basiseinheit steht; andere Einheiten bleiben unverändert.
+func BaseUnit(unit string) string {
+	p, _, ok := splitSI(CanonicalUnit(unit))
+	if !ok || p == "" {
+		return unit
+	}
+	if p == "µ" {
+		if rest, found := strings.CutPrefix(unit, "u"); found {
+			return rest
+		}
+	}
+	return strings.TrimPrefix(unit, p)
+}
+
+// CanonicalUnit führt eine Einheit in beliebiger Schreibweise ("kΩ", "uA", "degC") auf die
+// Decoder-Form zurück ("kOhm", "µA", "°C"), z.B. für Convert und Alarm-Regeln.
+func CanonicalUnit(unit string) string {
+	unit = unitAliases.Replace(unit)
+	if p, ok := strings.CutPrefix(unit, "u"); ok && p != "" {
+		unit = "µ" + p
+	}
+	return unit
+}
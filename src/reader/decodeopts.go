@@ -0,0 +1,68 @@
+package reader
+
+import "fmt"
+
+// BitRef adressiert ein Bit im Frame (Byte-Index, Bit 0 = LSB).
+type BitRef struct {
+	Byte int
+	Bit  int
+}
+
+func (r BitRef) in(b []byte) bool {
+	return r.Byte >= 0 && r.Byte < len(b) && r.Bit >= 0 && r.Bit < 8 && b[r.Byte]&(1<<r.Bit) != 0
+}
+
+// DecodeOptions legt fest, welche Bits Dezimalpunkt und SI-Präfix steuern.
+// Eng verwandte Gerätevarianten unterscheiden sich oft nur hier.
+type DecodeOptions struct {
+	// Dezimalpunkt für die Anzeige x.xxx, xx.xx, xxx.x (in dieser Prüfreihenfolge)
+	DecimalPoints [3]BitRef
+
+	Nano  BitRef
+	Micro BitRef
+	Milli BitRef
+	Kilo  BitRef
+	Mega  BitRef
+}
+
+// DefaultDecodeOptions: Mapping des HP-90EPC.
+func DefaultDecodeOptions() DecodeOptions {
+	return DecodeOptions{
+		DecimalPoints: [3]BitRef{{3, 3}, {5, 3}, {7, 3}},
+		Nano:          BitRef{9, 2},
+		Micro:         BitRef{9, 3},
+		Kilo:          BitRef{9, 1},
+		Milli:         BitRef{10, 3},
+		Mega:          BitRef{10, 1},
+	}
+}
+
+// Validate prüft, dass alle Bits innerhalb eines Frames der Länge frameLen liegen.
+func (o DecodeOptions) Validate(frameLen int) error {
+	refs := map[string]BitRef{
+		"decimal_points[0]": o.DecimalPoints[0],
+		"decimal_points[1]": o.DecimalPoints[1],
+		"decimal_points[2]": o.DecimalPoints[2],
+		"nano":              o.Nano,
+		"micro":             o.Micro,
+		"milli":             o.Milli,
+		"kilo":              o.Kilo,
+		"mega":              o.Mega,
+	}
+	for name, r := range refs {
+		if r.Byte < 0 || r.Byte >= frameLen || r.Bit < 0 || r.Bit > 7 {
+			return fmt.Errorf("decode option %s: byte %d bit %d out of range", name, r.Byte, r.Bit)
+		}
+	}
+	return nil
+}
+
+// decimals liefert die Anzahl Nachkommastellen (0..3) laut Dezimalpunkt-Bits.
+func (o *DecodeOptions) decimals(b []byte) int {
+	for i, r := range o.DecimalPoints {
+		if r.in(b) {
+			return 3 - i
+		}
+	}
+	return 0
+}
@@ -0,0 +1,49 @@
+package reader
+
+import (
+	"errors"
+	"io/fs"
+	"net"
+	"os"
+	"runtime"
+	"strings"
+)
+
+// Klassifizierte Verbindungsfehler für Status.LastError; die Rohmeldung steht nur im Debug-Log.
+var (
+	// ErrUnplugged: der Gerätepfad ist verschwunden (USB abgezogen) oder war nie da
+	ErrUnplugged = errors.New("device unplugged")
+	// ErrPortDenied: keine Rechte auf den Port (Linux: Gruppe dialout/uucp)
+	ErrPortDenied = errors.New("permission denied on serial port")
+	// ErrPortBusy: der Port gehört einem laufenden Reader, ein Test würde ihm Bytes wegnehmen
+	ErrPortBusy = errors.New("port is in use by a running reader")
+)
+
+// classifyErr ordnet einen Fehler beim Öffnen/Lesen ein: nil = vorübergehend (Timeout),
+// ErrUnplugged/ErrPortDenied = bekannte Ursache, sonst err unverändert.
+func classifyErr(port string, err error) error {
+	if err == nil {
+		return nil
+	}
+	var ne net.Error
+	if errors.Is(err, os.ErrDeadlineExceeded) || (errors.As(err, &ne) && ne.Timeout()) {
+		return nil
+	}
+	if errors.Is(err, fs.ErrPermission) {
+		return ErrPortDenied
+	}
+	if devicePathGone(port) || errors.Is(err, fs.ErrNotExist) {
+		return ErrUnplugged
+	}
+	return err
+}
+
+// devicePathGone meldet, ob ein lokaler Gerätepfad (z.B. /dev/ttyUSB0) nicht mehr existiert.
+// COM-Ports unter Windows und Netzwerk-Ports lassen sich so nicht prüfen.
+func devicePathGone(port string) bool {
+	if runtime.GOOS == "windows" || !strings.HasPrefix(port, "/") {
+		return false
+	}
+	_, err := os.Stat(port)
+	return errors.Is(err, fs.ErrNotExist)
+}
@@ -0,0 +1,86 @@
+package reader
+
+import (
+	"sync"
+	"time"
+
+	"hp90epc/model"
+)
+
+// frameLimiter begrenzt die Weitergabe an LatestBuffer und Logger auf einen Mindestabstand.
+// Zu frühe Frames ersetzen den wartenden; der neueste wird per Timer nachgereicht,
+// sodass der serielle Lesepfad nie wartet.
+type frameLimiter struct {
+	min    time.Duration
+	latest LatestSetter
+	logger Logger
+
+	mu      sync.Mutex
+	last    time.Time
+	pending *model.Measurement
+	timer   *time.Timer
+	stopped bool
+}
+
+func newFrameLimiter(hz float64, latest LatestSetter, logger Logger) *frameLimiter {
+	return &frameLimiter{
+		min:    time.Duration(float64(time.Second) / hz),
+		latest: latest,
+		logger: logger,
+	}
+}
+
+// Set erfüllt LatestSetter für RunLoop bzw. SimSource.
+func (f *frameLimiter) Set(m *model.Measurement) {
+	f.mu.Lock()
+	wait := f.min - time.Since(f.last)
+	if wait <= 0 && f.timer == nil {
+		f.last = time.Now()
+		f.mu.Unlock()
+		f.publish(m)
+		return
+	}
+	f.pending = m
+	if f.timer == nil && !f.stopped {
+		f.timer = time.AfterFunc(wait, f.flush)
+	}
+	f.mu.Unlock()
+}
+
+func (f *frameLimiter) flush() {
+	f.mu.Lock()
+	m := f.pending
+	f.pending = nil
+	f.timer = nil
+	if f.stopped {
+		m = nil
+	}
+	if m != nil {
+		f.last = time.Now()
+	}
+	f.mu.Unlock()
+	if m != nil {
+		f.publish(m)
+	}
+}
+
+// stop verwirft einen wartenden Frame, damit nach dem Stop nichts mehr nachkommt.
+func (f *frameLimiter) stop() {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.stopped = true
+	f.pending = nil
+	if f.timer != nil {
+		f.timer.Stop()
+		f.timer = nil
+	}
+}
+
+func (f *frameLimiter) publish(m *model.Measurement) {
+	if f.latest != nil {
+		f.latest.Set(m)
+	}
+	if f.logger != nil {
+		f.logger.Push(m)
+	}
+}
@@ -3,7 +3,9 @@ package reader
 import (
 	"context"
 	"errors"
+	"fmt"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"hp90epc/logging"
@@ -11,24 +13,83 @@ import (
 )
 
 type Status struct {
-	Port        string    `json:"port"`
-	Baud        int       `json:"baud"`
-	Connected   bool      `json:"connected"`
+	Port string `json:"port"`
+	Baud int    `json:"baud"`
+	// PortOpen: serielles Handle offen; Connected: zusätzlich frische Frames.
+	// PortOpen && !Connected deutet auf falsche Baudrate, !PortOpen auf Kabel/Port.
+	PortOpen  bool `json:"port_open"`
+	Connected bool `json:"connected"`
+	// Warming: Port offen, aber seit dem Öffnen noch kein gültiger Frame (Parser synchronisiert sich).
+	// Höchstens ein Stale-Fenster lang, danach gilt wieder "Port offen, keine Frames".
+	Warming     bool      `json:"warming"`
 	LastFrameAt time.Time `json:"last_frame_at"`
 	LastError   string    `json:"last_error"`
+	// Unplugged: Gerätepfad ist verschwunden (USB abgezogen); LastError ist dann "device unplugged"
+	Unplugged bool `json:"unplugged"`
+
+	// Reconnect-Backoff: Versuche seit dem letzten gültigen Frame und nächster Versuch
+	Retries     int       `json:"retries"`
+	BackoffMs   int64     `json:"backoff_ms"`
+	NextRetryAt time.Time `json:"next_retry_at"`
+
+	StaleAfterMs int64 `json:"stale_after_ms"`
+
+	// Signalqualität: Summen seit Start und Frames/s im letzten Fenster.
+	// Viele Resyncs = schlechtes Kabel oder falsche Baudrate.
+	TotalFrames    int64   `json:"total_frames"`
+	TotalResyncs   int64   `json:"total_resyncs"`
+	TotalZeroReads int64   `json:"total_zero_reads"`
+	FPS            float64 `json:"fps"`
+	// WatchdogRestarts: Neustarts durch den Watchdog, weil der offene Port keine Frames mehr lieferte
+	WatchdogRestarts int64 `json:"watchdog_restarts"`
+
+	// Verbindungsqualität über lange Läufe: Beginn der laufenden Verbindung (null, solange getrennt),
+	// Wiederverbindungen nach einer Lücke > Stale-Fenster und Summe aller verbundenen Zeiten
+	ConnectedSince time.Time `json:"connected_since"`
+	Reconnects     int       `json:"reconnects"`
+	TotalUptimeMs  int64     `json:"total_uptime_ms"`
+
+	// openedAt: Zeitpunkt, zu dem der Port geöffnet wurde (für Warming)
+	openedAt time.Time
+	// uptime: Summe der abgeschlossenen Verbindungsphasen; everConnected: die nächste ist eine Wiederverbindung
+	uptime        time.Duration
+	everConnected bool
 }
 
+// Grenzen für das Stale-Fenster: zu klein meldet ständig Abbrüche, zu groß versteckt echte.
+const (
+	MinStaleAfter = 500 * time.Millisecond
+	MaxStaleAfter = 60 * time.Second
+)
+
+// DefaultStatusEvery: höchstens so oft aktualisiert ein gültiger Frame den Status.
+// Bei hohen Frameraten spart das Lock-Wechsel; LastFrameAt bleibt weit genauer als das Stale-Fenster.
+const DefaultStatusEvery = 200 * time.Millisecond
+
 type Manager struct {
 	mu sync.RWMutex
 
 	latest *model.LatestBuffer
 	logger *logging.Logger
+	proto  Protocol
 
 	cancel  context.CancelFunc
 	running bool
+	// parent: Kontext des letzten StartCtx, Neustarts laufen darunter weiter
+	parent context.Context
+	// gen: Generation des laufenden RunLoop, damit ein alter Loop nach Restart keinen Status überschreibt
+	gen int
 
-	staleAfter time.Duration
-	status     Status
+	staleAfter  time.Duration
+	statusEvery time.Duration
+	simInterval time.Duration
+	// maxFrameHz > 0: höchstens so viele Messungen/s an LatestBuffer und Logger
+	maxFrameHz float64
+	unitStyle  model.UnitStyle
+	readBuf    int
+	// watchdog > 0: Neustart, wenn der offene Port so lange keine Frames liefert
+	watchdog time.Duration
+	status   Status
 }
 
 func NewManager(latest *model.LatestBuffer, logger *logging.Logger, stale time.Duration) *Manager {
@@ -36,10 +97,13 @@ func NewManager(latest *model.LatestBuffer, logger *logging.Logger, stale time.D
 		stale = 3 * time.Second
 	}
 	return &Manager{
-		latest:     latest,
-		logger:     logger,
-		staleAfter: stale,
-		status:     Status{},
+		latest:      latest,
+		logger:      logger,
+		proto:       HP90EPC{},
+		staleAfter:  stale,
+		statusEvery: DefaultStatusEvery,
+		simInterval: DefaultSimInterval,
+		status:      Status{},
 	}
 }
 
@@ -55,16 +119,194 @@ func (m *Manager) GetStatus() Status {
 	} else {
 		st.Connected = false
 	}
+	if st.Connected || !st.PortOpen || time.Since(st.openedAt) > stale {
+		st.Warming = false
+	}
+	uptime := st.uptime
+	if !st.ConnectedSince.IsZero() {
+		// getrennt: die Phase endete mit dem letzten Frame, abgeschlossen wird sie erst beim nächsten
+		end := st.LastFrameAt
+		if st.Connected {
+			end = time.Now()
+		}
+		uptime += end.Sub(st.ConnectedSince)
+		if !st.Connected {
+			st.ConnectedSince = time.Time{}
+		}
+	}
+	st.TotalUptimeMs = uptime.Milliseconds()
+	st.StaleAfterMs = stale.Milliseconds()
 	return st
 }
 
-func (m *Manager) setStatus(fn func(*Status)) {
+// SetProtocol wählt das Frame-Protokoll; greift beim nächsten Start/SetPort.
+func (m *Manager) SetProtocol(p Protocol) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.proto = p
+}
+
+func (m *Manager) Protocol() Protocol {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.proto
+}
+
+// SetStaleAfter ändert das Fenster, nach dem ohne Frames "nicht verbunden" gilt; wirkt sofort.
+func (m *Manager) SetStaleAfter(d time.Duration) error {
+	if d < MinStaleAfter || d > MaxStaleAfter {
+		return fmt.Errorf("stale after must be between %v and %v", MinStaleAfter, MaxStaleAfter)
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.staleAfter = d
+	return nil
+}
+
+// SetStatusEvery drosselt die Status-Updates pro Frame; 0 = jeder Frame. Greift beim nächsten Start.
+func (m *Manager) SetStatusEvery(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.statusEvery = d
+}
+
+// SetMaxFrameHz begrenzt die weitergegebenen Messungen pro Sekunde (neueste gewinnt); 0 = aus.
+// Greift beim nächsten Start.
+func (m *Manager) SetMaxFrameHz(hz float64) {
+	if hz < 0 {
+		hz = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.maxFrameHz = hz
+}
+
+// SetReadBufSize legt die Größe des Lesepuffers in Bytes fest; <= 0 = Default (256).
+// Greift beim nächsten Start.
+func (m *Manager) SetReadBufSize(n int) {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.readBuf = n
+}
+
+// SetUnitStyle legt die Schreibweise der Einheiten fest (z.B. "Ω" statt "Ohm"). Greift beim nächsten Start.
+func (m *Manager) SetUnitStyle(s model.UnitStyle) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.unitStyle = s
+}
+
+// UnitStyle liefert die eingestellte Schreibweise, z.B. für einzeln dekodierte Frames.
+func (m *Manager) UnitStyle() model.UnitStyle {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	return m.unitStyle
+}
+
+// SetSimInterval setzt die Messrate für Port SimPort; greift beim nächsten Start.
+func (m *Manager) SetSimInterval(d time.Duration) {
+	if d <= 0 {
+		d = DefaultSimInterval
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.simInterval = d
+}
+
+// ResetStats setzt die Summen seit Start und den letzten Fehler zurück, z.B. nach einem Kabeltausch.
+func (m *Manager) ResetStats() {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.status.TotalFrames = 0
+	m.status.TotalResyncs = 0
+	m.status.TotalZeroReads = 0
+	m.status.WatchdogRestarts = 0
+	m.status.Reconnects = 0
+	m.status.uptime = 0
+	m.status.LastError = ""
+	m.status.Unplugged = false
+}
+
+// linkUp beginnt beim ersten Frame nach einer Lücke > Stale-Fenster eine neue Verbindungsphase.
+// Aufruf unter m.mu, vor dem Setzen von LastFrameAt.
+func (m *Manager) linkUp(s *Status, now time.Time) {
+	if !s.ConnectedSince.IsZero() && now.Sub(s.LastFrameAt) <= m.staleAfter {
+		return
+	}
+	m.linkDown(s)
+	if s.everConnected {
+		s.Reconnects++
+	}
+	s.everConnected = true
+	s.ConnectedSince = now
+}
+
+// linkDown schließt eine laufende Verbindungsphase mit dem letzten Frame ab. Aufruf unter m.mu.
+func (m *Manager) linkDown(s *Status) {
+	if s.ConnectedSince.IsZero() {
+		return
+	}
+	if s.LastFrameAt.After(s.ConnectedSince) {
+		s.uptime += s.LastFrameAt.Sub(s.ConnectedSince)
+	}
+	s.ConnectedSince = time.Time{}
+}
+
+// statusThrottle lässt höchstens alle every Nanosekunden ein Status-Update aus OnFrameOK durch.
+// Die Prüfung kommt ohne m.mu aus, nur durchgelassene Frames nehmen den Lock.
+type statusThrottle struct {
+	every int64
+	last  atomic.Int64
+}
+
+// allow meldet, ob der Frame zum Zeitpunkt now den Status aktualisieren soll.
+func (t *statusThrottle) allow(now time.Time) bool {
+	if now.UnixNano()-t.last.Load() < t.every {
+		return false
+	}
+	t.last.Store(now.UnixNano())
+	return true
+}
+
+// reset: der nächste Frame wird in jedem Fall durchgelassen.
+func (t *statusThrottle) reset() {
+	t.last.Store(0)
+}
+
+// frameOK: Status-Update für einen gültigen Frame der Generation gen.
+func (m *Manager) frameOK(gen int, now time.Time) {
+	m.setStatus(gen, func(s *Status) {
+		m.linkUp(s, now)
+		s.LastFrameAt = now
+		s.Warming = false
+		s.LastError = ""
+		s.Unplugged = false
+		s.Retries = 0
+		s.BackoffMs = 0
+		s.NextRetryAt = time.Time{}
+	})
+}
+
+func (m *Manager) setStatus(gen int, fn func(*Status)) {
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	if gen != m.gen {
+		return
+	}
 	fn(&m.status)
 }
 
+// Start startet den Reader (bzw. startet ihn neu) unter context.Background(); siehe StartCtx.
 func (m *Manager) Start(port string, baud int) error {
+	return m.StartCtx(context.Background(), port, baud)
+}
+
+// StartCtx startet den Reader unterhalb von parent: endet parent, stoppt der Reader wie mit Stop.
+// Spätere Neustarts über SetPort oder den Watchdog bleiben unter demselben parent.
+func (m *Manager) StartCtx(parent context.Context, port string, baud int) error {
 	m.mu.Lock()
 
 	if m.running && m.cancel != nil {
@@ -72,29 +314,115 @@ func (m *Manager) Start(port string, baud int) error {
 		m.running = false
 	}
 
-	ctx, cancel := context.WithCancel(context.Background())
+	proto := m.proto
+	every := m.statusEvery.Nanoseconds()
+	sim := SimSource{Interval: m.simInterval}
+	maxHz := m.maxFrameHz
+	style := m.unitStyle
+	readBuf := m.readBuf
+	ctx, cancel := context.WithCancel(parent)
+	m.parent = parent
 	m.cancel = cancel
 	m.running = true
+	m.gen++
+	gen := m.gen
+	m.linkDown(&m.status)
 	m.status.Port = port
 	m.status.Baud = baud
+	m.status.PortOpen = false
 	m.status.Connected = false
+	m.status.Warming = false
 	m.status.LastError = ""
+	m.status.Unplugged = false
+	m.status.Retries = 0
+	m.status.BackoffMs = 0
+	m.status.NextRetryAt = time.Time{}
 
 	m.mu.Unlock()
 
+	go m.runWatchdog(ctx, gen, port, baud)
+
+	throttle := &statusThrottle{every: every}
+
 	go func() {
-		err := RunLoop(ctx, port, baud, m.latest, m.logger, func() {
-			m.setStatus(func(s *Status) {
-				s.LastFrameAt = time.Now()
-				s.LastError = ""
-			})
-		})
+		hooks := Hooks{
+			OnFrameOK: func() {
+				if now := time.Now(); throttle.allow(now) {
+					m.frameOK(gen, now)
+				}
+			},
+			OnPortState: func(open bool) {
+				m.setStatus(gen, func(s *Status) {
+					s.PortOpen = open
+					s.Warming = open
+					if open {
+						s.openedAt = time.Now()
+						// erster Frame nach dem Öffnen soll Warming sofort beenden, nicht erst nach statusEvery
+						throttle.reset()
+					} else {
+						s.FPS = 0
+					}
+				})
+			},
+			OnStats: func(st Stats) {
+				m.setStatus(gen, func(s *Status) {
+					s.TotalFrames += int64(st.Frames)
+					s.TotalResyncs += int64(st.Resyncs)
+					s.TotalZeroReads += int64(st.ZeroReads)
+					s.FPS = 0
+					if st.Window > 0 {
+						s.FPS = float64(st.Frames) / st.Window.Seconds()
+					}
+				})
+			},
+			OnError: func(err error) {
+				m.setStatus(gen, func(s *Status) {
+					s.LastError = err.Error()
+					s.Unplugged = errors.Is(err, ErrUnplugged)
+				})
+			},
+			OnRetry: func(retries int, delay time.Duration) {
+				m.setStatus(gen, func(s *Status) {
+					s.Retries = retries
+					s.BackoffMs = delay.Milliseconds()
+					s.NextRetryAt = time.Now().Add(delay)
+				})
+			},
+		}
 
+		// nil-Pointer nicht als Interface durchreichen, RunLoop prüft auf nil
+		var latest LatestSetter = m.latest
+		var logger Logger
+		if m.logger != nil {
+			logger = m.logger
+		}
+		if !style.IsDefault() {
+			latest, logger = &unitRenderer{style: style, latest: latest, logger: logger}, nil
+		}
+		if maxHz > 0 {
+			lim := newFrameLimiter(maxHz, latest, logger)
+			defer lim.stop()
+			latest, logger = lim, nil
+		}
+
+		var err error
+		if port == SimPort {
+			err = sim.Run(ctx, latest, logger, hooks)
+		} else {
+			err = RunLoop(ctx, port, baud, proto, latest, logger, hooks, readBuf)
+		}
 		if err != nil && !errors.Is(err, context.Canceled) {
-			m.setStatus(func(s *Status) {
+			m.setStatus(gen, func(s *Status) {
 				s.LastError = err.Error()
 			})
 		}
+		if parent.Err() != nil {
+			m.mu.Lock()
+			if gen == m.gen {
+				m.stopLocked()
+			}
+			m.mu.Unlock()
+		}
 	}()
 
 	return nil
@@ -103,14 +431,32 @@ func (m *Manager) Start(port string, baud int) error {
 func (m *Manager) Stop() {
 	m.mu.Lock()
 	defer m.mu.Unlock()
+	m.stopLocked()
+}
+
+func (m *Manager) stopLocked() {
 	if m.cancel != nil {
 		m.cancel()
 	}
 	m.running = false
+	m.gen++
+	m.linkDown(&m.status)
+	m.status.PortOpen = false
 	m.status.Connected = false
+	m.status.Warming = false
 }
 
+// SetPort startet den Reader mit neuem Port unter dem Kontext des letzten StartCtx neu.
 func (m *Manager) SetPort(port string, baud int) error {
-	return m.Start(port, baud)
+	return m.StartCtx(m.parentCtx(), port, baud)
 }
 
+// parentCtx: Kontext des letzten StartCtx, vor dem ersten Start context.Background().
+func (m *Manager) parentCtx() context.Context {
+	m.mu.RLock()
+	defer m.mu.RUnlock()
+	if m.parent == nil {
+		return context.Background()
+	}
+	return m.parent
+}
@@ -0,0 +1,88 @@
+package reader
+
+import (
+	"testing"
+	"time"
+)
+
+func TestStatusThrottle(t *testing.T) {
+	th := &statusThrottle{every: int64(200 * time.Millisecond)}
+	t0 := time.Now()
+	steps := []struct {
+		after time.Duration
+		want  bool
+	}{
+		{0, true},
+		{50 * time.Millisecond, false},
+		{199 * time.Millisecond, false},
+		{200 * time.Millisecond, true},
+		{300 * time.Millisecond, false},
+		{450 * time.Millisecond, true},
+	}
+	for _, s := range steps {
+		if got := th.allow(t0.Add(s.after)); got != s.want {
+			t.Errorf("allow(+%v) = %v, want %v", s.after, got, s.want)
+		}
+	}
+	th.reset()
+	if !th.allow(t0.Add(460 * time.Millisecond)) {
+		t.Error("allow after reset = false, want true")
+	}
+
+	// every = 0: jeder Frame
+	th = &statusThrottle{}
+	for i := 0; i < 3; i++ {
+		if !th.allow(t0) {
+			t.Fatal("every=0 dropped a frame")
+		}
+	}
+}
+
+func TestFrameOKUpdatesStatus(t *testing.T) {
+	m := NewManager(nil, nil, time.Second)
+	now := time.Now()
+	m.frameOK(m.gen, now)
+	st := m.GetStatus()
+	if !st.LastFrameAt.Equal(now) || !st.Connected {
+		t.Errorf("last_frame_at/connected = %v/%v, want %v/true", st.LastFrameAt, st.Connected, now)
+	}
+	// Frames einer alten Generation ändern nichts mehr
+	m.frameOK(m.gen-1, now.Add(time.Second))
+	if st := m.GetStatus(); !st.LastFrameAt.Equal(now) {
+		t.Errorf("stale generation updated last_frame_at to %v", st.LastFrameAt)
+	}
+}
+
+// BenchmarkOnFrameOK: Kosten des OnFrameOK-Hooks pro Frame ohne und mit Drosselung;
+// locks/op zeigt, wie oft der Manager-Lock dabei genommen wird. Ein paralleler Leser
+// (wie /api/reader/status) erzeugt die Konkurrenz um m.mu.
+func BenchmarkOnFrameOK(b *testing.B) {
+	for _, every := range []time.Duration{0, DefaultStatusEvery} {
+		b.Run("every="+every.String(), func(b *testing.B) {
+			m := NewManager(nil, nil, time.Second)
+			th := &statusThrottle{every: every.Nanoseconds()}
+			done := make(chan struct{})
+			go func() {
+				for {
+					select {
+					case <-done:
+						return
+					default:
+						m.GetStatus()
+					}
+				}
+			}()
+			defer close(done)
+
+			locks := 0
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				if now := time.Now(); th.allow(now) {
+					m.frameOK(m.gen, now)
+					locks++
+				}
+			}
+			b.ReportMetric(float64(locks)/float64(b.N), "locks/op")
+		})
+	}
+}
@@ -0,0 +1,138 @@
+package reader
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"sort"
+	"sync"
+	"time"
+
+	"hp90epc/model"
+)
+
+var ErrNoDevice = errors.New("no HP-90EPC found on any serial port")
+
+// sortCOMPorts sortiert numerisch nach der Portnummer: COM2 vor COM10.
+func sortCOMPorts(ports []string) {
+	sort.Slice(ports, func(i, j int) bool {
+		a, b := ports[i], ports[j]
+		if len(a) != len(b) {
+			return len(a) < len(b)
+		}
+		return a < b
+	})
+}
+
+// Probe öffnet den Port (auch tcp://) kurz und zählt gültige Frames, bis timeout abläuft
+// oder zwei Frames dekodiert wurden.
+func Probe(proto Protocol, port string, baud int, timeout time.Duration) (int, error) {
+	ctx, cancel := context.WithTimeout(context.Background(), timeout)
+	defer cancel()
+	return probe(ctx, proto, port, baud, 2, nil)
+}
+
+// probe: gemeinsamer Kern von Probe und TestPort. Öffnet port mit eigener Quelle und dekodiert,
+// bis want Frames gesehen sind, ctx endet oder ein Lesefehler auftritt; onFrame (optional)
+// bekommt jede dekodierte Messung. Ein Ende durch ctx ist kein Fehler.
+func probe(ctx context.Context, proto Protocol, port string, baud, want int, onFrame func(*model.Measurement)) (frames int, err error) {
+	s, err := openSource(port, baud)
+	if err != nil {
+		return 0, err
+	}
+	// ctx schließt die Quelle, damit ein hängendes Read sofort zurückkehrt
+	var closeOnce sync.Once
+	closeSrc := func() { closeOnce.Do(func() { s.Close() }) }
+	stopClose := context.AfterFunc(ctx, closeSrc)
+	defer func() {
+		stopClose()
+		closeSrc()
+	}()
+
+	fs := newFrameSync(proto)
+	tmp := make([]byte, 256)
+	for ctx.Err() == nil && frames < want {
+		n, err := s.Read(tmp)
+		if err != nil && !errors.Is(err, io.EOF) {
+			if ctx.Err() != nil {
+				// Quelle wurde wegen Timeout/Abbruch geschlossen
+				break
+			}
+			return frames, err
+		}
+		for _, c := range tmp[:n] {
+			if !fs.push(c) {
+				continue
+			}
+			if m := proto.Decode(fs.frame); m != nil {
+				frames++
+				if onFrame != nil {
+					onFrame(m)
+				}
+			}
+		}
+	}
+	return frames, nil
+}
+
+// AutoDetect probt alle Kandidaten aus ListPorts und liefert den ersten Port mit gültigen Frames.
+func AutoDetect(proto Protocol, baud int, timeout time.Duration) (string, error) {
+	ports, err := ListPorts()
+	if err != nil {
+		return "", err
+	}
+	for _, p := range ports {
+		if n, err := Probe(proto, p, baud, timeout); err == nil && n > 0 {
+			return p, nil
+		}
+	}
+	return "", ErrNoDevice
+}
+
+// TestResult: Ergebnis von TestPort für /api/device/test.
+type TestResult struct {
+	OK         bool               `json:"ok"`
+	FramesSeen int                `json:"frames_seen"`
+	Sample     *model.Measurement `json:"sample_measurement"`
+	Error      string             `json:"error,omitempty"`
+	// DurationMs: Dauer bis zum Ende des Tests (genug Frames, Fehler oder Timeout)
+	DurationMs int64 `json:"duration_ms"`
+}
+
+// testFrames: nach so vielen gültigen Frames ist der Test vorzeitig bestanden
+const testFrames = 3
+
+// TestPort öffnet port mit eigener Quelle, unabhängig von einem laufenden Manager, wartet bis
+// timeout auf gültige Frames und schließt wieder. Sample ist der zuletzt dekodierte Frame.
+// Den Port eines laufenden Readers nicht testen: die Bytes würden zwischen beiden aufgeteilt.
+func TestPort(ctx context.Context, proto Protocol, port string, baud int, timeout time.Duration) (res TestResult) {
+	start := time.Now()
+	defer func() { res.DurationMs = time.Since(start).Milliseconds() }()
+
+	if port == SimPort {
+		m := simMeasurement(simRanges[0], 0)
+		m.Timestamp = time.Now()
+		res.OK, res.FramesSeen, res.Sample = true, 1, m
+		return res
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, timeout)
+	defer cancel()
+	n, err := probe(ctx, proto, port, baud, testFrames, func(m *model.Measurement) {
+		m.Timestamp = time.Now()
+		res.Sample = m
+	})
+	res.FramesSeen = n
+	if err != nil {
+		if c := classifyErr(port, err); c != nil {
+			err = c
+		}
+		res.Error = err.Error()
+	}
+	res.OK = res.FramesSeen > 0
+	if !res.OK && res.Error == "" {
+		res.Error = fmt.Sprintf("no valid frame within %v (wrong baud rate or protocol?)", timeout)
+	}
+	return res
+}
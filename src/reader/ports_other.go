@@ -0,0 +1,30 @@
+//go:build !windows
+
+package reader
+
+import (
+	"path/filepath"
+	"runtime"
+	"sort"
+)
+
+// ListPorts liefert die Kandidaten für serielle Geräte des aktuellen OS.
+func ListPorts() ([]string, error) {
+	if runtime.GOOS == "darwin" {
+		return globPorts("/dev/tty.usbserial*", "/dev/tty.usbmodem*", "/dev/cu.usbserial*", "/dev/cu.usbmodem*")
+	}
+	return globPorts("/dev/ttyUSB*", "/dev/ttyACM*")
+}
+
+func globPorts(patterns ...string) ([]string, error) {
+	out := []string{}
+	for _, p := range patterns {
+		matches, err := filepath.Glob(p)
+		if err != nil {
+			return nil, err
+		}
+		out = append(out, matches...)
+	}
+	sort.Strings(out)
+	return out, nil
+}
@@ -0,0 +1,72 @@
+package reader
+
+import (
+	"context"
+	"net"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestSortCOMPorts(t *testing.T) {
+	ports := []string{"COM10", "COM3", "COM1", "COM21", "COM2"}
+	sortCOMPorts(ports)
+	want := []string{"COM1", "COM2", "COM3", "COM10", "COM21"}
+	if !reflect.DeepEqual(ports, want) {
+		t.Errorf("sorted = %q, want %q", ports, want)
+	}
+}
+
+// serveStream: TCP-Gateway, das frames Frames sendet und die Verbindung dann offen hält.
+func serveStream(t *testing.T, frames int) string {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { ln.Close() })
+	stream := syntheticStream(t, frames)
+	go func() {
+		c, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer c.Close()
+		_, _ = c.Write(stream)
+		_, _ = c.Read(make([]byte, 1))
+	}()
+	return TCPPrefix + ln.Addr().String()
+}
+
+// Probe liest wie RunLoop auch von einem Seriell-Ethernet-Gateway.
+func TestProbeTCP(t *testing.T) {
+	n, err := Probe(HP90EPC{}, serveStream(t, 4), 2400, 2*time.Second)
+	if err != nil || n < 2 {
+		t.Errorf("Probe = %d, %v; want >= 2 frames", n, err)
+	}
+}
+
+// TestPort und Probe teilen sich probe: gleiche Quelle, nur andere Frame-Zahl und Ergebnis.
+func TestTestPortTCP(t *testing.T) {
+	res := TestPort(context.Background(), HP90EPC{}, serveStream(t, 4), 2400, 2*time.Second)
+	if !res.OK || res.FramesSeen < testFrames || res.Sample == nil || res.Sample.Timestamp.IsZero() {
+		t.Errorf("TestPort = %+v, want ok with >= %d frames and a sample", res, testFrames)
+	}
+
+	// nur ein Frame: bis zum Timeout warten, dann trotzdem bestanden
+	res = TestPort(context.Background(), HP90EPC{}, serveStream(t, 1), 2400, 300*time.Millisecond)
+	if !res.OK || res.FramesSeen != 1 || res.Error != "" {
+		t.Errorf("TestPort(1 frame) = %+v", res)
+	}
+
+	// niemand lauscht: Fehler aus dem Öffnen, keine Frames
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	if err != nil {
+		t.Fatal(err)
+	}
+	addr := ln.Addr().String()
+	ln.Close()
+	res = TestPort(context.Background(), HP90EPC{}, TCPPrefix+addr, 2400, time.Second)
+	if res.OK || res.Error == "" {
+		t.Errorf("TestPort(closed) = %+v, want error", res)
+	}
+}
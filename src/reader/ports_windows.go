@@ -0,0 +1,41 @@
+//go:build windows
+
+package reader
+
+import (
+	"errors"
+
+	"golang.org/x/sys/windows/registry"
+)
+
+// serialCommKey: hier trägt Windows jeden vorhandenen seriellen Port ein (Wert = "COMx"),
+// auch USB-Adapter; ohne einen Port fehlt der Schlüssel.
+const serialCommKey = `HARDWARE\DEVICEMAP\SERIALCOMM`
+
+// ListPorts liest die COM-Ports aus der Registry statt sie zu öffnen: belegte Ports bleiben
+// in der Liste und laufende Programme werden nicht gestört.
+func ListPorts() ([]string, error) {
+	k, err := registry.OpenKey(registry.LOCAL_MACHINE, serialCommKey, registry.QUERY_VALUE)
+	if errors.Is(err, registry.ErrNotExist) {
+		return []string{}, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+	defer k.Close()
+
+	names, err := k.ReadValueNames(0)
+	if err != nil {
+		return nil, err
+	}
+	out := make([]string, 0, len(names))
+	for _, name := range names {
+		port, _, err := k.GetStringValue(name)
+		if err != nil || port == "" {
+			continue
+		}
+		out = append(out, port)
+	}
+	sortCOMPorts(out)
+	return out, nil
+}
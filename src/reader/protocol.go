@@ -0,0 +1,124 @@
+package reader
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+
+	"hp90epc/model"
+)
+
+// Protocol beschreibt das Frame-Format eines Messgeräts: Länge, Byte-Synchronisation und Dekodierung.
+type Protocol interface {
+	FrameLen() int
+	// Sync: darf b an Position idx eines Frames stehen?
+	Sync(b byte, idx int) bool
+	// Decode bekommt einen vollständigen Frame (len == FrameLen) und liefert nil, wenn er unbrauchbar ist.
+	Decode(frame []byte) *model.Measurement
+}
+
+// DefaultProtocol ist der Name des eingebauten HP-90EPC-Protokolls.
+const DefaultProtocol = "hp90epc"
+
+var protocols = map[string]Protocol{
+	DefaultProtocol: HP90EPC{},
+}
+
+// LookupProtocol liefert das Protokoll zum Config-Namen; leer = DefaultProtocol.
+func LookupProtocol(name string) (Protocol, error) {
+	if name == "" {
+		name = DefaultProtocol
+	}
+	p, ok := protocols[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown protocol %q (known: %v)", name, ProtocolNames())
+	}
+	return p, nil
+}
+
+func ProtocolNames() []string {
+	out := make([]string, 0, len(protocols))
+	for name := range protocols {
+		out = append(out, name)
+	}
+	sort.Strings(out)
+	return out
+}
+
+// HP90EPC: 14-Byte-Frames im Cyrustek-Stil, das High-Nibble jedes Bytes ist Index+1.
+// Options: abweichende Dezimalpunkt-/Präfix-Bits für Gerätevarianten; nil = DefaultDecodeOptions.
+type HP90EPC struct {
+	Options *DecodeOptions
+}
+
+func (HP90EPC) FrameLen() int { return 14 }
+
+func (HP90EPC) Sync(b byte, idx int) bool {
+	return b&0xF0 == byte((idx+1)<<4) // idx=0 -> 0x10, ... idx=13 -> 0xE0
+}
+
+func (p HP90EPC) Decode(frame []byte) *model.Measurement {
+	if p.Options != nil {
+		return decodeFrameOpts(frame, p.Options)
+	}
+	return decodeFrame(frame)
+}
+
+var ErrInvalidFrame = errors.New("invalid frame")
+
+// DecodeFrame prüft Länge und Sync-Muster eines einzelnen Frames und dekodiert ihn,
+// z.B. um gemeldete Rohdaten ohne Gerät nachzuvollziehen.
+func DecodeFrame(p Protocol, frame []byte) (*model.Measurement, error) {
+	if len(frame) != p.FrameLen() {
+		return nil, fmt.Errorf("%w: length %d, want %d", ErrInvalidFrame, len(frame), p.FrameLen())
+	}
+	for i, b := range frame {
+		if !p.Sync(b, i) {
+			return nil, fmt.Errorf("%w: byte %d (%02X) breaks the sync pattern", ErrInvalidFrame, i, b)
+		}
+	}
+	m := p.Decode(frame)
+	if m == nil {
+		return nil, fmt.Errorf("%w: not decodable", ErrInvalidFrame)
+	}
+	return m, nil
+}
+
+// frameSync setzt aus einem Bytestrom Frames nach den Sync-Regeln des Protokolls zusammen.
+type frameSync struct {
+	proto   Protocol
+	frame   []byte
+	idx     int
+	resyncs int
+}
+
+func newFrameSync(p Protocol) *frameSync {
+	return &frameSync{proto: p, frame: make([]byte, p.FrameLen())}
+}
+
+// push nimmt ein Byte auf und liefert true, sobald frame komplett ist.
+func (fs *frameSync) push(b byte) bool {
+	if fs.proto.Sync(b, fs.idx) {
+		fs.frame[fs.idx] = b
+		fs.idx++
+		if fs.idx == len(fs.frame) {
+			fs.idx = 0
+			return true
+		}
+		return false
+	}
+
+	// mismatch: resync. Das störende Byte wird nicht verworfen, wenn es selbst einen Frame
+	// beginnen kann (beim HP-90EPC 0x1X) – auch mitten in einem angefangenen Frame.
+	// Andere Startkandidaten kann es im verworfenen Teil nicht geben: dort stehen nur Bytes
+	// mit Index > 0, sonst wäre schon früher neu begonnen worden. Damit geht nach Störbytes
+	// kein gültiger Frame verloren.
+	fs.resyncs++
+	if fs.proto.Sync(b, 0) {
+		fs.frame[0] = b
+		fs.idx = 1
+	} else {
+		fs.idx = 0
+	}
+	return false
+}
@@ -0,0 +1,87 @@
+package reader
+
+import (
+	"reflect"
+	"testing"
+)
+
+// syntheticStream: frames gültige Frames aus decodeFixtures, vor jedem Frame etwas Störung
+// (angefangener Frame, einzelne Nicht-Sync-Bytes), wie sie ein wackeliges Kabel liefert.
+func syntheticStream(t testing.TB, frames int) []byte {
+	noise := [][]byte{
+		nil,
+		{0x10, 0x20, 0x35},
+		{0xFF, 0x00},
+		{0x17},
+	}
+	var out []byte
+	for i := 0; i < frames; i++ {
+		out = append(out, noise[i%len(noise)]...)
+		out = append(out, frameHex(t, decodeFixtures[i%len(decodeFixtures)].hex)...)
+	}
+	return out
+}
+
+// BenchmarkParseStream: Byte-Schleife aus RunLoop (frameSync + Decode) über einen großen Strom.
+func BenchmarkParseStream(b *testing.B) {
+	stream := syntheticStream(b, 1000)
+	proto := HP90EPC{}
+	b.SetBytes(int64(len(stream)))
+	b.ReportAllocs()
+	b.ResetTimer()
+	for i := 0; i < b.N; i++ {
+		fs := newFrameSync(proto)
+		frames := 0
+		for _, c := range stream {
+			if fs.push(c) && proto.Decode(fs.frame) != nil {
+				frames++
+			}
+		}
+		if frames != 1000 {
+			b.Fatalf("decoded %d frames, want 1000", frames)
+		}
+	}
+}
+
+func TestFrameSyncResync(t *testing.T) {
+	a := frameHex(t, decodeFixtures[0].hex)
+	b := frameHex(t, decodeFixtures[2].hex)
+	want := []string{decodeFixtures[0].str, decodeFixtures[2].str}
+
+	tests := []struct {
+		name  string
+		noise []byte
+	}{
+		{"ohne", nil},
+		{"Nicht-Sync-Bytes", []byte{0x00, 0xFF, 0x42, 0x07}},
+		{"einzelnes 0x10", []byte{0x10}},
+		{"mehrere Startbytes", []byte{0x10, 0x1F, 0x10}},
+		{"angefangener Frame", a[:5]},
+		// 13 Bytes eines Frames: das Startbyte des echten Frames kommt dort an, wo 0xE0 erwartet wird
+		{"fast ein Frame", a[:13]},
+		{"Frame ohne Startbyte", a[1:]},
+		{"Startbyte mitten im Frame", append(append([]byte{}, a[:6]...), 0x10, 0x20, 0x35)},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var stream []byte
+			stream = append(stream, tt.noise...)
+			stream = append(stream, a...)
+			stream = append(stream, b...)
+
+			fs := newFrameSync(HP90EPC{})
+			var got []string
+			for _, c := range stream {
+				if fs.push(c) {
+					got = append(got, decodeFrame(fs.frame).ValueStr)
+				}
+			}
+			if !reflect.DeepEqual(got, want) {
+				t.Errorf("frames = %q, want %q", got, want)
+			}
+			if len(tt.noise) > 0 && fs.resyncs == 0 {
+				t.Error("noise did not count as resync")
+			}
+		})
+	}
+}
@@ -2,14 +2,14 @@ package reader
 
 import (
 	"context"
-	"fmt"
-	"log"
-	"strings"
+	"errors"
+	"io"
+	"log/slog"
+	"math"
+	"math/rand"
+	"sync"
 	"time"
 
-	"github.com/tarm/serial"
-
-	"hp90epc/logging"
 	"hp90epc/model"
 )
 
@@ -21,14 +21,106 @@ type Logger interface {
 	Push(*model.Measurement)
 }
 
+// Hooks: optionale Rückmeldungen aus RunLoop an den Aufrufer.
+type Hooks struct {
+	OnFrameOK func()
+	// OnPortState: true direkt nach erfolgreichem OpenPort, false nach Close
+	OnPortState func(open bool)
+	// OnStats: Zähler des letzten Fensters (etwa 1s), danach werden sie zurückgesetzt
+	OnStats func(Stats)
+	// OnRetry: nächster Verbindungsversuch in delay; retries zählt seit dem letzten gültigen Frame
+	OnRetry func(retries int, delay time.Duration)
+	// OnError: Öffnen oder Lesen ist fehlgeschlagen, err ist klassifiziert (ErrUnplugged, ErrPortDenied, …).
+	// Vorübergehende Fehler (Timeouts) werden nicht gemeldet; der nächste gültige Frame hebt den Fehler auf.
+	OnError func(err error)
+}
+
+// Stats: Parser-Zähler eines Zeitfensters.
+type Stats struct {
+	Frames    int
+	ZeroReads int
+	Resyncs   int
+	Window    time.Duration
+}
+
+const (
+	backoffMin = 600 * time.Millisecond
+	backoffMax = 10 * time.Second
+)
+
+// readTimeout: Read kehrt spätestens danach zurück, damit ctx auch dann greift,
+// wenn Close() ein blockierendes Read auf manchen Plattformen nicht aufweckt.
+const readTimeout = 200 * time.Millisecond
+
+// backoff: exponentiell von backoffMin bis backoffMax, ±10% Jitter.
+type backoff struct {
+	cur     time.Duration
+	retries int
+}
+
+func (b *backoff) next() time.Duration {
+	if b.cur == 0 {
+		b.cur = backoffMin
+	} else {
+		b.cur *= 2
+		if b.cur > backoffMax {
+			b.cur = backoffMax
+		}
+	}
+	b.retries++
+	jitter := time.Duration(rand.Int63n(int64(b.cur/5))) - b.cur/10
+	return b.cur + jitter
+}
+
+func (b *backoff) reset() {
+	b.cur = 0
+	b.retries = 0
+}
+
+// defaultReadBuf: Lesepuffer, wenn RunLoop keine Größe mitbekommt.
+const defaultReadBuf = 256
+
 func RunLoop(
 	ctx context.Context,
 	port string,
 	baud int,
+	proto Protocol,
 	latest LatestSetter,
 	logger Logger,
-	onFrameOK func(),
+	hooks Hooks,
+	readBuf int,
 ) error {
+	if readBuf <= 0 {
+		readBuf = defaultReadBuf
+	}
+	var bo backoff
+
+	// wait meldet den Retry und wartet, bricht bei ctx sofort ab
+	wait := func() error {
+		d := bo.next()
+		if hooks.OnRetry != nil {
+			hooks.OnRetry(bo.retries, d)
+		}
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case <-time.After(d):
+			return nil
+		}
+	}
+
+	// report meldet einen klassifizierten Fehler; die Rohmeldung nur ins Debug-Log
+	report := func(err error) {
+		c := classifyErr(port, err)
+		if c == nil {
+			return
+		}
+		slog.Debug("reader error", "port", port, "err", err, "class", c)
+		if hooks.OnError != nil {
+			hooks.OnError(c)
+		}
+	}
+
 	// reconnect loop
 	for {
 		select {
@@ -37,36 +129,58 @@ func RunLoop(
 		default:
 		}
 
-		c := &serial.Config{
-			Name: port,
-			Baud: baud,
-			// Blockierend lesen: wir verlassen uns auf Close() beim Stop/Ctx-Cancel
-			ReadTimeout: 0,
-		}
-
-		s, err := serial.OpenPort(c)
+		s, err := sourceOpener(port, baud)
 		if err != nil {
-			// Port nicht da → kurz warten und retry
-			select {
-			case <-ctx.Done():
-				return ctx.Err()
-			case <-time.After(600 * time.Millisecond):
-				continue
+			// Port nicht da → mit Backoff retry
+			report(err)
+			if err := wait(); err != nil {
+				return err
 			}
+			continue
+		}
+
+		if hooks.OnPortState != nil {
+			hooks.OnPortState(true)
 		}
 
 		// read loop (stream parser, no blocking "exactly 14 bytes")
 		err = func() error {
-			defer s.Close()
-
-			frame := make([]byte, 14)
-			idx := 0
-			tmp := make([]byte, 256)
+			fs := newFrameSync(proto)
+			tmp := make([]byte, readBuf)
 			frames := 0
 			zeroReads := 0
-			resyncs := 0
 			lastLog := time.Now()
 
+			// Zähler des laufenden Fensters melden und zurücksetzen
+			flushStats := func() {
+				if hooks.OnStats != nil {
+					hooks.OnStats(Stats{
+						Frames:    frames,
+						ZeroReads: zeroReads,
+						Resyncs:   fs.resyncs,
+						Window:    time.Since(lastLog),
+					})
+				}
+				frames = 0
+				zeroReads = 0
+				fs.resyncs = 0
+				lastLog = time.Now()
+			}
+
+			// Abbruch schließt die Quelle auch mitten in einem hängenden Read (Watchdog, Portwechsel)
+			var closeOnce sync.Once
+			closeSrc := func() { closeOnce.Do(func() { s.Close() }) }
+			stopClose := context.AfterFunc(ctx, closeSrc)
+
+			defer func() {
+				stopClose()
+				closeSrc()
+				flushStats()
+				if hooks.OnPortState != nil {
+					hooks.OnPortState(false)
+				}
+			}()
+
 			for {
 				select {
 				case <-ctx.Done():
@@ -75,62 +189,41 @@ func RunLoop(
 				}
 
 				n, err := s.Read(tmp)
-				if err != nil {
-					// bei Timeout etc. weiter, bei echten Errors raus
+				if err != nil && !errors.Is(err, io.EOF) {
 					// tarm/serial liefert meist plain error strings – wir treaten alles als reconnect-worthy
 					return err
 				}
 
 				if n == 0 {
-					// Timeout -> wir bleiben im Loop
+					// Timeout (POSIX: io.EOF, Windows: 0 Bytes) -> ctx prüfen, Stats weiterführen
 					zeroReads++
-					continue
 				}
 
-				for i := 0; i < n; i++ {
-					b := tmp[i]
-					want := byte((idx + 1) << 4) // idx=0 -> 0x10, ... idx=13 -> 0xE0
-
-					if (b & 0xF0) == want {
-						frame[idx] = b
-						idx++
-						if idx == 14 {
-							// Frame komplett
-							m := decodeFrame(frame)
-							if m != nil {
-								if latest != nil {
-									latest.Set(m)
-								}
-								if logger != nil {
-									logger.Push(m)
-								}
-								if onFrameOK != nil {
-									onFrameOK()
-								}
-								frames++
-							}
-							idx = 0
-						}
+				for _, c := range tmp[:n] {
+					if !fs.push(c) {
 						continue
 					}
-
-					// mismatch: resync
-					resyncs++
-					if (b & 0xF0) == 0x10 {
-						// Byte könnte Start eines neuen Frames sein
-						frame[0] = b
-						idx = 1
-					} else {
-						idx = 0
+					// Frame komplett
+					m := proto.Decode(fs.frame)
+					if m != nil {
+						m.Timestamp = time.Now()
+						if latest != nil {
+							latest.Set(m)
+						}
+						if logger != nil {
+							logger.Push(m)
+						}
+						bo.reset()
+						if hooks.OnFrameOK != nil {
+							hooks.OnFrameOK()
+						}
+						frames++
 					}
 				}
 
 				if time.Since(lastLog) >= time.Second {
-					log.Printf("reader: fps=%d zero_reads=%d resyncs=%d idx=%d", frames, zeroReads, resyncs, idx)
-					frames = 0
-					zeroReads = 0
-					resyncs = 0
-					lastLog = time.Now()
+					slog.Debug("reader stats", "fps", frames, "zero_reads", zeroReads, "resyncs", fs.resyncs, "idx", fs.idx)
+					flushStats()
 				}
 			}
 		}()
@@ -139,12 +232,10 @@ func RunLoop(
 		if ctx.Err() != nil {
 			return ctx.Err()
 		}
+		report(err)
 
-		// kleiner backoff
-		select {
-		case <-ctx.Done():
-			return ctx.Err()
-		case <-time.After(400 * time.Millisecond):
+		if err := wait(); err != nil {
+			return err
 		}
 	}
 }
@@ -179,7 +270,46 @@ func parseDigit(b byte) int {
 	}
 }
 
+// Segmentmuster für "L" (links + unten), Teil der Overload-Anzeige " 0L "
+const segL = 0x68
+
+// frameSegBits: Frame-Bit → Standardsegment; im Frame ist Bit 0 = b, 1 = g, 2 = c, 3 = d, 4 = a, 5 = f, 6 = e
+// (abgeleitet aus den Ziffernmustern in parseDigit).
+var frameSegBits = [7]uint8{1, 6, 2, 3, 0, 5, 4}
+
+// stdSegments rechnet eine Ziffer aus dem Frame in eine a..g-Maske (Bit 0 = a) um.
+func stdSegments(db byte) uint8 {
+	var out uint8
+	for bit, seg := range frameSegBits {
+		if db&(1<<bit) != 0 {
+			out |= 1 << seg
+		}
+	}
+	return out
+}
+
+// isOverload erkennt die OL-Anzeige: ein "L", sonst nur "0"/leere Stellen.
+func isOverload(digitBytes []byte) bool {
+	hasL := false
+	for _, db := range digitBytes {
+		switch db &^ (1 << 7) {
+		case segL:
+			hasL = true
+		case 0x7d, 0x00:
+		default:
+			return false
+		}
+	}
+	return hasL
+}
+
+var defaultDecodeOptions = DefaultDecodeOptions()
+
 func decodeFrame(b []byte) *model.Measurement {
+	return decodeFrameOpts(b, &defaultDecodeOptions)
+}
+
+func decodeFrameOpts(b []byte, opts *DecodeOptions) *model.Measurement {
 	if len(b) != 14 {
 		return nil
 	}
@@ -190,9 +320,9 @@ func decodeFrame(b []byte) *model.Measurement {
 		sign = -1.0
 	}
 
-	// Digits
-	digitBytes := make([]byte, 4)
-	digits := make([]int, 4)
+	// Digits (Arrays statt Slices: keine Heap-Allokation pro Frame)
+	var digitBytes [4]byte
+	var digits [4]int
 	numeric := true
 
 	for i := 0; i < 4; i++ {
@@ -215,25 +345,18 @@ func decodeFrame(b []byte) *model.Measurement {
 	}
 
 	// Decimal point
-	div := 1.0
-	switch {
-	case b[3]&(1<<3) != 0:
-		div = 1000.0
-	case b[5]&(1<<3) != 0:
-		div = 100.0
-	case b[7]&(1<<3) != 0:
-		div = 10.0
-	}
+	decimals := opts.decimals(b)
+	div := math.Pow10(decimals)
 
 	floatval := float64(intval) / div
 	floatval *= sign
 
 	// Prefix flags
-	isNano := b[9]&(1<<2) != 0
-	isMicro := b[9]&(1<<3) != 0
-	isKilo := b[9]&(1<<1) != 0
-	isMilli := b[10]&(1<<3) != 0
-	isMega := b[10]&(1<<1) != 0
+	isNano := opts.Nano.in(b)
+	isMicro := opts.Micro.in(b)
+	isKilo := opts.Kilo.in(b)
+	isMilli := opts.Milli.in(b)
+	isMega := opts.Mega.in(b)
 
 	if isNano {
 		floatval /= 1e9
@@ -251,29 +374,33 @@ func decodeFrame(b []byte) *model.Measurement {
 		floatval *= 1e6
 	}
 
-	// Mode + flags
-	isAC := b[0]&(1<<3) != 0
-	isDC := b[0]&(1<<2) != 0
-	auto := b[0]&(1<<1) != 0
+	// Annunciators: einzige Quelle für Mode und die Top-Level-Flags
+	ann := model.Annunciators{
+		AC:      b[0]&(1<<3) != 0,
+		DC:      b[0]&(1<<2) != 0,
+		Auto:    b[0]&(1<<1) != 0,
+		Diode:   b[9]&(1<<0) != 0,
+		Beep:    b[10]&(1<<0) != 0,
+		Rel:     b[11]&(1<<1) != 0,
+		Hold:    b[11]&(1<<0) != 0,
+		LowBatt: b[12]&(1<<0) != 0,
+	}
 
 	isPercent := b[10]&(1<<2) != 0
 	isFarad := b[11]&(1<<3) != 0
 	isOhm := b[11]&(1<<2) != 0
-	isRel := b[11]&(1<<1) != 0
-	isHold := b[11]&(1<<0) != 0
 
 	isAmp := b[12]&(1<<3) != 0
 	isVolt := b[12]&(1<<2) != 0
 	isHz := b[12]&(1<<1) != 0
-	lowBatt := b[12]&(1<<0) != 0
 
 	// °C: bei deinen Beispielen nur bei ... E4 (low nibble bit2)
 	isCelsius := (b[13] & 0x04) != 0
 
 	mode := ""
-	if isAC {
+	if ann.AC {
 		mode = "AC"
-	} else if isDC {
+	} else if ann.DC {
 		mode = "DC"
 	}
 
@@ -323,21 +450,25 @@ func decodeFrame(b []byte) *model.Measurement {
 	}
 
 	// ValueStr
+	overload := !numeric && isOverload(digitBytes[:])
+
 	valueStr := "????"
+	if overload {
+		valueStr = "OL"
+	}
 	if numeric {
-		s := fmt.Sprintf("%d%d%d%d", digits[0], digits[1], digits[2], digits[3])
-		switch {
-		case b[3]&(1<<3) != 0:
-			s = fmt.Sprintf("%c.%c%c%c", s[0], s[1], s[2], s[3])
-		case b[5]&(1<<3) != 0:
-			s = fmt.Sprintf("%c%c.%c%c", s[0], s[1], s[2], s[3])
-		case b[7]&(1<<3) != 0:
-			s = fmt.Sprintf("%c%c%c.%c", s[0], s[1], s[2], s[3])
-		}
+		var buf [6]byte
+		s := buf[:0]
 		if sign < 0 {
-			s = "-" + s
+			s = append(s, '-')
 		}
-		valueStr = s
+		for i, d := range digits {
+			if decimals > 0 && i == 4-decimals {
+				s = append(s, '.')
+			}
+			s = append(s, byte('0'+d))
+		}
+		valueStr = string(s)
 	}
 
 	var valPtr *float64
@@ -346,13 +477,39 @@ func decodeFrame(b []byte) *model.Measurement {
 		valPtr = &v
 	}
 
-	// Raw hex
-	var sb strings.Builder
+	// Rohbild der Anzeige; Bit 7 der Ziffern trägt Vorzeichen/Dezimalpunkte und ist oben schon ausgewertet
+	segs := model.Segments{Minus: sign < 0}
+	for i, db := range digitBytes {
+		segs.Digits[i] = stdSegments(db)
+	}
+	if decimals > 0 && decimals < 4 {
+		segs.Points[3-decimals] = true
+	}
+
+	// Raw hex, "10 20 ..." – ohne fmt, das ist der heiße Pfad
+	const hexDigits = "0123456789ABCDEF"
+
+	// Diagnose nur für unlesbare Anzeigen, der Normalfall bleibt ohne Allokation
+	var diag *model.DecodeDiag
+	if !numeric {
+		diag = &model.DecodeDiag{}
+		for i, db := range digitBytes {
+			diag.Digits[i] = model.DigitDiag{
+				Byte:  string([]byte{hexDigits[db>>4], hexDigits[db&0x0F]}),
+				Digit: digits[i],
+				Known: digits[i] >= 0,
+			}
+			if digits[i] < 0 {
+				diag.Failed = append(diag.Failed, i)
+			}
+		}
+	}
+	raw := make([]byte, 0, len(b)*3)
 	for i, x := range b {
 		if i > 0 {
-			sb.WriteByte(' ')
+			raw = append(raw, ' ')
 		}
-		fmt.Fprintf(&sb, "%02X", x)
+		raw = append(raw, hexDigits[x>>4], hexDigits[x&0x0F])
 	}
 
 	return &model.Measurement{
@@ -360,13 +517,19 @@ func decodeFrame(b []byte) *model.Measurement {
 		ValueStr: valueStr,
 		Unit:     fullUnit,
 		Mode:     mode,
-		Auto:     auto,
-		Hold:     isHold,
-		Rel:      isRel,
-		LowBatt:  lowBatt,
-		RawHex:   sb.String(),
+		Auto:     ann.Auto,
+		Hold:     ann.Hold,
+		Rel:      ann.Rel,
+		LowBatt:  ann.LowBatt,
+		Diode:    ann.Diode,
+		// Durchgang = Summer-Symbol im Ohm-Bereich
+		Continuity: ann.Beep && isOhm,
+		Beep:       ann.Beep,
+		Overload:   overload,
+		RawHex:     string(raw),
+
+		Annunciators: ann,
+		Segments:     segs,
+		Diag:         diag,
 	}
 }
-
-// (optional) wenn du später Unit-Tests willst:
-var _ = logging.LogStatus{}
@@ -0,0 +1,259 @@
+package reader
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"reflect"
+	"strings"
+	"testing"
+)
+
+// frameHex wandelt "10 20 ..." in Bytes um.
+func frameHex(t testing.TB, s string) []byte {
+	t.Helper()
+	b, err := hex.DecodeString(strings.ReplaceAll(s, " ", ""))
+	if err != nil {
+		t.Fatalf("bad fixture %q: %v", s, err)
+	}
+	return b
+}
+
+func sameValue(got, want float64) bool {
+	return math.Abs(got-want) <= 1e-12*math.Max(1, math.Abs(want))
+}
+
+// Aufgezeichnete bzw. nach dem Frame-Layout zusammengesetzte Frames (Hex -> erwartete Messung).
+var decodeFixtures = []struct {
+	name  string
+	hex   string
+	value float64
+	str   string
+	unit  string
+	mode  string
+}{
+	{"V DC", "16 20 35 4D 5B 61 7F 82 97 A0 B0 C0 D4 E0", 1.234, "1.234", "V", "DC"},
+	{"V DC negativ", "16 2F 3D 43 5E 6F 7D 87 9D A0 B0 C0 D4 E0", -5, "-05.00", "V", "DC"},
+	{"mV AC", "18 20 35 45 5B 61 7F 8A 97 A0 B8 C0 D4 E0", 0.1234, "123.4", "mV", "AC"},
+	{"Ohm x.xxx", "12 27 3D 4B 5E 67 7E 81 95 A0 B0 C4 D0 E0", 0.567, "0.567", "Ohm", ""},
+	{"Ohm xx.xx", "10 20 35 47 5D 6F 7D 87 9D A0 B0 C4 D0 E0", 10, "10.00", "Ohm", ""},
+	{"Ohm xxx.x", "10 27 3D 42 57 61 75 8F 9D A0 B0 C4 D0 E0", 47, "047.0", "Ohm", ""},
+	{"kOhm", "12 22 37 41 55 6F 7D 87 9D A2 B0 C4 D0 E0", 47000, "47.00", "kOhm", ""},
+	{"MOhm", "10 20 35 4D 5B 67 7D 87 9D A0 B2 C4 D0 E0", 1.2e6, "1.200", "MOhm", ""},
+	{"µA DC", "14 27 3D 41 5F 63 7E 8F 9D A8 B0 C0 D8 E0", 35e-6, "035.0", "µA", "DC"},
+	{"nF", "10 27 3D 47 5D 62 77 89 95 A4 B0 C8 D0 E0", 4.7e-9, "004.7", "nF", ""},
+	{"Hz", "10 23 3E 47 5D 67 7D 8F 9D A0 B0 C0 D2 E0", 500, "500.0", "Hz", ""},
+	{"Prozent", "10 27 3D 43 5E 67 7D 8F 9D A0 B4 C0 D0 E0", 50, "050.0", "%", ""},
+	// °C: DC-Flag gesetzt, Temperatur hat trotzdem keinen Modus
+	{"°C", "14 27 3D 47 5D 65 7B 81 9F A0 B0 C0 D0 E4", 23, "0023", "°C", ""},
+}
+
+func TestDecodeFrameFixtures(t *testing.T) {
+	for _, tt := range decodeFixtures {
+		t.Run(tt.name, func(t *testing.T) {
+			m := decodeFrame(frameHex(t, tt.hex))
+			if m == nil {
+				t.Fatal("decodeFrame returned nil")
+			}
+			if m.Value == nil {
+				t.Fatalf("value nil, value_str %q", m.ValueStr)
+			}
+			if !sameValue(*m.Value, tt.value) {
+				t.Errorf("value = %v, want %v", *m.Value, tt.value)
+			}
+			if m.ValueStr != tt.str {
+				t.Errorf("value_str = %q, want %q", m.ValueStr, tt.str)
+			}
+			if m.Unit != tt.unit || m.Mode != tt.mode {
+				t.Errorf("unit/mode = %q/%q, want %q/%q", m.Unit, m.Mode, tt.unit, tt.mode)
+			}
+			if m.RawHex != tt.hex {
+				t.Errorf("raw = %q, want %q", m.RawHex, tt.hex)
+			}
+			if m.Diag != nil || m.Overload {
+				t.Errorf("numeric frame has diag %+v / overload %v", m.Diag, m.Overload)
+			}
+		})
+	}
+}
+
+func TestDecodeFramePrefixScaling(t *testing.T) {
+	// dieselben Ziffern "12.34" mit wechselndem Präfix; Value ist immer in der Basiseinheit
+	base := frameHex(t, "10 20 35 45 5B 69 7F 82 97 A0 B0 C0 D4 E0")
+	tests := []struct {
+		name string
+		set  BitRef
+		want float64
+		unit string
+	}{
+		{"ohne", BitRef{-1, 0}, 12.34, "V"},
+		{"milli", BitRef{10, 3}, 0.01234, "mV"},
+		{"kilo", BitRef{9, 1}, 12340, "kV"},
+		{"mikro", BitRef{9, 3}, 12.34e-6, "µV"},
+		{"nano", BitRef{9, 2}, 12.34e-9, "nV"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := append([]byte(nil), base...)
+			if tt.set.Byte >= 0 {
+				f[tt.set.Byte] |= 1 << tt.set.Bit
+			}
+			m := decodeFrame(f)
+			if m == nil || m.Value == nil {
+				t.Fatalf("no value: %+v", m)
+			}
+			if !sameValue(*m.Value, tt.want) {
+				t.Errorf("value = %v, want %v", *m.Value, tt.want)
+			}
+			if m.ValueStr != "12.34" {
+				t.Errorf("value_str = %q, want display digits unchanged", m.ValueStr)
+			}
+			if m.Unit != tt.unit {
+				t.Errorf("unit = %q, want %q", m.Unit, tt.unit)
+			}
+		})
+	}
+}
+
+func TestDecodeFrameAnnunciators(t *testing.T) {
+	m := decodeFrame(frameHex(t, "14 27 3D 47 5D 67 7D 80 95 A0 B0 C3 D5 E0"))
+	if !m.Hold || !m.Rel || !m.LowBatt || m.Auto {
+		t.Errorf("hold/rel/low_batt/auto = %v/%v/%v/%v, want true/true/true/false", m.Hold, m.Rel, m.LowBatt, m.Auto)
+	}
+	if m.Annunciators.Hold != m.Hold || m.Annunciators.Rel != m.Rel || m.Annunciators.LowBatt != m.LowBatt {
+		t.Errorf("annunciators %+v disagree with top-level flags", m.Annunciators)
+	}
+
+	// Summer im Ohm-Bereich = Durchgangsprüfung
+	m = decodeFrame(frameHex(t, "10 27 3D 47 5D 60 75 8D 9B A0 B1 C4 D0 E0"))
+	if !m.Beep || !m.Continuity {
+		t.Errorf("beep/continuity = %v/%v, want true/true", m.Beep, m.Continuity)
+	}
+}
+
+func TestSegmentMapping(t *testing.T) {
+	// Frame-Muster aus parseDigit -> Standardmaske a..g (Bit 0 = a)
+	tests := []struct {
+		frame byte
+		digit int
+		std   uint8
+	}{
+		{0x7d, 0, 0x3f},
+		{0x05, 1, 0x06},
+		{0x5b, 2, 0x5b},
+		{0x1f, 3, 0x4f},
+		{0x27, 4, 0x66},
+		{0x3e, 5, 0x6d},
+		{0x7e, 6, 0x7d},
+		{0x15, 7, 0x07},
+		{0x7f, 8, 0x7f},
+		{0x3f, 9, 0x6f},
+		{segL, -1, 0x38},
+	}
+	for _, tt := range tests {
+		if d := parseDigit(tt.frame); d != tt.digit {
+			t.Errorf("parseDigit(%02X) = %d, want %d", tt.frame, d, tt.digit)
+		}
+		// Bit 7 (Vorzeichen/Dezimalpunkt) darf das Ergebnis nicht ändern
+		if d := parseDigit(tt.frame | 0x80); d != tt.digit {
+			t.Errorf("parseDigit(%02X) = %d, want %d", tt.frame|0x80, d, tt.digit)
+		}
+		if s := stdSegments(tt.frame); s != tt.std {
+			t.Errorf("stdSegments(%02X) = %02X, want %02X", tt.frame, s, tt.std)
+		}
+	}
+
+	m := decodeFrame(frameHex(t, "16 2F 3D 43 5E 6F 7D 87 9D A0 B0 C0 D4 E0"))
+	want := [4]uint8{0x3f, 0x6d, 0x3f, 0x3f}
+	if m.Segments.Digits != want {
+		t.Errorf("segments = %02X, want %02X", m.Segments.Digits, want)
+	}
+	if m.Segments.Points != [4]bool{false, true, false, false} || !m.Segments.Minus {
+		t.Errorf("points/minus = %v/%v, want point after digit 1 and minus", m.Segments.Points, m.Segments.Minus)
+	}
+}
+
+func TestDecodeFrameUnknownSegments(t *testing.T) {
+	// Stellen 1 und 3 tragen Muster, die keiner Ziffer entsprechen
+	m := decodeFrame(frameHex(t, "14 20 35 44 52 61 7F 81 91 A0 B0 C0 D4 E0"))
+	if m == nil {
+		t.Fatal("decodeFrame returned nil")
+	}
+	if m.Value != nil || m.ValueStr != "????" || m.Overload {
+		t.Errorf("value/value_str/overload = %v/%q/%v, want nil/\"????\"/false", m.Value, m.ValueStr, m.Overload)
+	}
+	if m.Unit != "V" || m.Mode != "DC" {
+		t.Errorf("unit/mode = %q/%q, annunciators must still decode", m.Unit, m.Mode)
+	}
+	if m.Diag == nil || !reflect.DeepEqual(m.Diag.Failed, []int{1, 3}) {
+		t.Fatalf("diag = %+v, want failed digits [1 3]", m.Diag)
+	}
+	if d := m.Diag.Digits[1]; d.Byte != "42" || d.Known {
+		t.Errorf("diag digit 1 = %+v, want byte 42 unknown", d)
+	}
+}
+
+func TestDecodeFrameMalformed(t *testing.T) {
+	valid := "16 20 35 4D 5B 61 7F 82 97 A0 B0 C0 D4 E0"
+	tests := []struct {
+		name string
+		hex  string
+	}{
+		{"zu kurz", "16 20 35 4D 5B 61 7F 82 97 A0 B0 C0 D4"},
+		{"zu lang", valid + " 10"},
+		{"leer", ""},
+		{"Nibble-Sync Byte 5", "16 20 35 4D 5B 11 7F 82 97 A0 B0 C0 D4 E0"},
+		{"Nibble-Sync Startbyte", "26 20 35 4D 5B 61 7F 82 97 A0 B0 C0 D4 E0"},
+		{"Nibble-Sync letztes Byte", "16 20 35 4D 5B 61 7F 82 97 A0 B0 C0 D4 F0"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			f := frameHex(t, tt.hex)
+			m, err := DecodeFrame(HP90EPC{}, f)
+			if !errors.Is(err, ErrInvalidFrame) || m != nil {
+				t.Errorf("DecodeFrame = %v, %v; want ErrInvalidFrame", m, err)
+			}
+			if len(f) != 14 && decodeFrame(f) != nil {
+				t.Error("decodeFrame accepted a frame of wrong length")
+			}
+		})
+	}
+
+	// der Parser verwirft einen Frame mit gebrochener Nibble-Folge und findet den nächsten
+	fs := newFrameSync(HP90EPC{})
+	stream := append(frameHex(t, tests[3].hex), frameHex(t, valid)...)
+	var got []string
+	for _, b := range stream {
+		if fs.push(b) {
+			got = append(got, decodeFrame(fs.frame).ValueStr)
+		}
+	}
+	if !reflect.DeepEqual(got, []string{"1.234"}) {
+		t.Errorf("frames from stream = %q, want only the valid one", got)
+	}
+}
+
+func TestDecodeOverload(t *testing.T) {
+	// Anzeige " 0.L " im MOhm-Bereich bei offenen Messspitzen
+	m := decodeFrame(frameHex(t, "10 20 30 47 5D 6E 78 80 90 A0 B2 C4 D0 E0"))
+	if m == nil {
+		t.Fatal("decodeFrame returned nil")
+	}
+	if !m.Overload || m.ValueStr != "OL" || m.Value != nil {
+		t.Errorf("overload/value_str/value = %v/%q/%v, want true/\"OL\"/nil", m.Overload, m.ValueStr, m.Value)
+	}
+	if m.Unit != "MOhm" {
+		t.Errorf("unit = %q, want MOhm", m.Unit)
+	}
+	if m.Segments.Digits[2] != 0x38 {
+		t.Errorf("segment of digit 2 = %02X, want L (38)", m.Segments.Digits[2])
+	}
+
+	// ein "L" neben einer echten Ziffer ist kein Overload, sondern ein kaputter Frame
+	f := frameHex(t, "10 20 30 47 5D 6E 78 80 90 A0 B2 C4 D0 E0")
+	f[7], f[8] = 0x80, 0x95 // Stelle 3: "1"
+	m = decodeFrame(f)
+	if m.Overload || m.ValueStr != "????" {
+		t.Errorf("overload/value_str = %v/%q, want false/\"????\"", m.Overload, m.ValueStr)
+	}
+}
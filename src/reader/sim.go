@@ -0,0 +1,132 @@
+package reader
+
+import (
+	"context"
+	"math"
+	"math/rand"
+	"strconv"
+	"time"
+
+	"hp90epc/model"
+)
+
+// SimPort: Port-Name, unter dem statt der seriellen Schnittstelle SimSource läuft (Demo/UI-Entwicklung ohne Gerät).
+const SimPort = "sim"
+
+// DefaultSimInterval entspricht grob der Framerate des echten Geräts.
+const DefaultSimInterval = 500 * time.Millisecond
+
+// SimSource erzeugt plausible Messungen: langsam schwankende DC-Spannung,
+// gelegentlich ein Wechsel auf AC-Spannung oder Widerstand.
+type SimSource struct {
+	// Interval zwischen zwei Messungen; <= 0 = DefaultSimInterval
+	Interval time.Duration
+}
+
+// simRange: ein simulierter Messbereich; base/swing in Anzeigeeinheit (unit),
+// scale rechnet wie decodeFrame in die Basiseinheit von Measurement.Value um.
+type simRange struct {
+	mode     string
+	unit     string
+	base     float64
+	swing    float64
+	decimals int
+	scale    float64
+}
+
+var simRanges = []simRange{
+	{mode: "DC", unit: "V", base: 3.3, swing: 0.05, decimals: 3, scale: 1},
+	{mode: "AC", unit: "V", base: 230, swing: 2, decimals: 1, scale: 1},
+	{mode: "", unit: "kOhm", base: 4.7, swing: 0.01, decimals: 3, scale: 1e3},
+}
+
+// Run liefert bis ctx endet Messungen an latest/logger und meldet über hooks wie RunLoop.
+func (s SimSource) Run(ctx context.Context, latest LatestSetter, logger Logger, hooks Hooks) error {
+	every := s.Interval
+	if every <= 0 {
+		every = DefaultSimInterval
+	}
+
+	if hooks.OnPortState != nil {
+		hooks.OnPortState(true)
+	}
+	frames := 0
+	lastStats := time.Now()
+	defer func() {
+		if hooks.OnPortState != nil {
+			hooks.OnPortState(false)
+		}
+	}()
+
+	t := time.NewTicker(every)
+	defer t.Stop()
+
+	start := time.Now()
+	cur := 0
+	nextSwitch := start.Add(simSwitchAfter())
+
+	for {
+		select {
+		case <-ctx.Done():
+			return ctx.Err()
+		case now := <-t.C:
+			// meistens DC, ab und zu kurz ein anderer Bereich
+			if now.After(nextSwitch) {
+				if cur == 0 {
+					cur = 1 + rand.Intn(len(simRanges)-1)
+				} else {
+					cur = 0
+				}
+				nextSwitch = now.Add(simSwitchAfter())
+			}
+
+			m := simMeasurement(simRanges[cur], now.Sub(start))
+			m.Timestamp = now
+			if latest != nil {
+				latest.Set(m)
+			}
+			if logger != nil {
+				logger.Push(m)
+			}
+			if hooks.OnFrameOK != nil {
+				hooks.OnFrameOK()
+			}
+			frames++
+
+			if w := time.Since(lastStats); w >= time.Second {
+				if hooks.OnStats != nil {
+					hooks.OnStats(Stats{Frames: frames, Window: w})
+				}
+				frames = 0
+				lastStats = time.Now()
+			}
+		}
+	}
+}
+
+// simSwitchAfter: zufällige Verweildauer in einem Bereich (20–60s)
+func simSwitchAfter() time.Duration {
+	return 20*time.Second + time.Duration(rand.Int63n(int64(40*time.Second)))
+}
+
+func simMeasurement(r simRange, elapsed time.Duration) *model.Measurement {
+	// langsame Sinus-Drift plus etwas Rauschen, auf die Anzeigestellen gerundet
+	v := r.base + r.swing*math.Sin(elapsed.Seconds()/10) + r.swing*0.1*rand.NormFloat64()
+	p := math.Pow10(r.decimals)
+	v = math.Round(v*p) / p
+	value := v * r.scale
+
+	ann := model.Annunciators{
+		AC:   r.mode == "AC",
+		DC:   r.mode == "DC",
+		Auto: true,
+	}
+	return &model.Measurement{
+		Value:        &value,
+		ValueStr:     strconv.FormatFloat(v, 'f', r.decimals, 64),
+		Unit:         r.unit,
+		Mode:         r.mode,
+		Auto:         ann.Auto,
+		Annunciators: ann,
+	}
+}
@@ -0,0 +1,41 @@
+package reader
+
+import (
+	"math"
+	"strconv"
+	"testing"
+	"time"
+
+	"hp90epc/model"
+)
+
+// Value steht wie beim Decoder in der Basiseinheit, ValueStr zeigt die Stellen im Bereich.
+func TestSimMeasurementBaseUnit(t *testing.T) {
+	for _, r := range simRanges {
+		t.Run(r.mode+" "+r.unit, func(t *testing.T) {
+			m := simMeasurement(r, 3*time.Second)
+			if m.Value == nil {
+				t.Fatal("Value = nil")
+			}
+			shown, err := strconv.ParseFloat(m.ValueStr, 64)
+			if err != nil {
+				t.Fatalf("ValueStr %q: %v", m.ValueStr, err)
+			}
+			display, err := model.Convert(*m.Value, m.Unit, m.Unit)
+			if err != nil {
+				t.Fatalf("Convert: %v", err)
+			}
+			if math.Abs(display-shown) > 1e-9*math.Max(1, math.Abs(shown)) {
+				t.Errorf("Value %v %s shows as %v, ValueStr = %q", *m.Value, m.Unit, display, m.ValueStr)
+			}
+		})
+	}
+}
+
+func TestSimMeasurementKiloOhm(t *testing.T) {
+	r := simRange{unit: "kOhm", base: 4.7, decimals: 3, scale: 1e3}
+	m := simMeasurement(r, 0)
+	if m.ValueStr != "4.700" || *m.Value != 4700 {
+		t.Errorf("got %v (%q), want 4700 (\"4.700\")", *m.Value, m.ValueStr)
+	}
+}
@@ -0,0 +1,68 @@
+package reader
+
+import (
+	"errors"
+	"io"
+	"net"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/tarm/serial"
+)
+
+// TCPPrefix: Port-Angaben der Form "tcp://host:port" lesen den Rohdatenstrom eines
+// Seriell-Ethernet-Gateways (ser2net im raw-Modus, esp-link, …) statt eines lokalen Geräts.
+// Die Baudrate stellt dann das Gateway ein.
+const TCPPrefix = "tcp://"
+
+// dialTimeout: so lange darf der Verbindungsaufbau zum Gateway dauern, danach Backoff
+const dialTimeout = 5 * time.Second
+
+// TCPAddr liefert host:port aus "tcp://host:port", sonst "".
+func TCPAddr(port string) string {
+	if !strings.HasPrefix(port, TCPPrefix) {
+		return ""
+	}
+	return strings.TrimPrefix(port, TCPPrefix)
+}
+
+// openSource öffnet die Datenquelle für RunLoop. Read kehrt wie beim seriellen Port
+// spätestens nach readTimeout zurück (0 Bytes bzw. io.EOF = Timeout).
+func openSource(port string, baud int) (io.ReadCloser, error) {
+	if addr := TCPAddr(port); addr != "" {
+		c, err := net.DialTimeout("tcp", addr, dialTimeout)
+		if err != nil {
+			return nil, err
+		}
+		return &tcpSource{Conn: c}, nil
+	}
+	return serial.OpenPort(&serial.Config{
+		Name:        port,
+		Baud:        baud,
+		ReadTimeout: readTimeout,
+	})
+}
+
+// sourceOpener öffnet die Quelle in RunLoop; Tests setzen hier eine Quelle im Speicher ein.
+var sourceOpener = openSource
+
+// tcpSource bildet das Timeout-Verhalten des seriellen Ports nach, damit ctx regelmäßig geprüft wird.
+type tcpSource struct {
+	net.Conn
+}
+
+func (t *tcpSource) Read(p []byte) (int, error) {
+	if err := t.SetReadDeadline(time.Now().Add(readTimeout)); err != nil {
+		return 0, err
+	}
+	n, err := t.Conn.Read(p)
+	if errors.Is(err, os.ErrDeadlineExceeded) {
+		return n, nil
+	}
+	if errors.Is(err, io.EOF) {
+		// Gegenstelle hat geschlossen – anders als beim seriellen Port kein Timeout, sondern Reconnect
+		return n, io.ErrUnexpectedEOF
+	}
+	return n, err
+}
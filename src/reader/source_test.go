@@ -0,0 +1,104 @@
+package reader
+
+import (
+	"context"
+	"io"
+	"strconv"
+	"testing"
+	"time"
+
+	"hp90epc/model"
+)
+
+// memSource liefert data in Stücken bis zur Puffergröße; loop = ohne Ende wiederholen
+// (Quelle mit hoher Rate), sonst danach nur noch Timeouts wie ein stiller Port.
+type memSource struct {
+	data    []byte
+	pos     int
+	loop    bool
+	reads   int
+	bufLens map[int]bool
+}
+
+func (s *memSource) Read(p []byte) (int, error) {
+	s.reads++
+	s.bufLens[len(p)] = true
+	if s.pos >= len(s.data) {
+		if !s.loop {
+			time.Sleep(time.Millisecond)
+			return 0, io.EOF
+		}
+		s.pos = 0
+	}
+	n := copy(p, s.data[s.pos:])
+	s.pos += n
+	return n, nil
+}
+
+func (s *memSource) Close() error { return nil }
+
+// withSource lässt RunLoop für die Dauer des Tests src statt des echten Ports öffnen.
+func withSource(t testing.TB, src io.ReadCloser) {
+	t.Helper()
+	prev := sourceOpener
+	sourceOpener = func(string, int) (io.ReadCloser, error) { return src, nil }
+	t.Cleanup(func() { sourceOpener = prev })
+}
+
+// countingSetter beendet RunLoop über cancel, sobald want Messungen angekommen sind.
+type countingSetter struct {
+	n, want int
+	cancel  context.CancelFunc
+}
+
+func (c *countingSetter) Set(*model.Measurement) {
+	c.n++
+	if c.n == c.want {
+		c.cancel()
+	}
+}
+
+func TestRunLoopReadBufSize(t *testing.T) {
+	const frames = 200
+	stream := syntheticStream(t, frames)
+	for _, size := range []int{14, 15, 100, 0} {
+		src := &memSource{data: stream, bufLens: map[int]bool{}}
+		withSource(t, src)
+
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		got := &countingSetter{want: frames, cancel: cancel}
+		_ = RunLoop(ctx, "mem", 2400, HP90EPC{}, got, nil, Hooks{}, size)
+		cancel()
+
+		if got.n != frames {
+			t.Errorf("size %d: decoded %d frames, want %d", size, got.n, frames)
+		}
+		want := size
+		if size == 0 {
+			want = defaultReadBuf
+		}
+		if len(src.bufLens) != 1 || !src.bufLens[want] {
+			t.Errorf("size %d: read buffer lengths %v, want only %d", size, src.bufLens, want)
+		}
+	}
+}
+
+// BenchmarkRunLoopReadBuf: RunLoop an einer Quelle, die immer Daten hat (schnelles Gateway,
+// hohe Baudrate). reads/frame entspricht den Read-Syscalls pro Messung an einem echten Port.
+func BenchmarkRunLoopReadBuf(b *testing.B) {
+	stream := syntheticStream(b, 1000)
+	for _, size := range []int{14, 64, 256, 4096} {
+		b.Run("buf="+strconv.Itoa(size), func(b *testing.B) {
+			src := &memSource{data: stream, loop: true, bufLens: map[int]bool{}}
+			withSource(b, src)
+			ctx, cancel := context.WithCancel(context.Background())
+			defer cancel()
+			got := &countingSetter{want: b.N, cancel: cancel}
+
+			b.ResetTimer()
+			_ = RunLoop(ctx, "mem", 2400, HP90EPC{}, got, nil, Hooks{}, size)
+			b.StopTimer()
+			b.ReportMetric(float64(src.reads)/float64(got.n), "reads/frame")
+		})
+	}
+}
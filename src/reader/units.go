@@ -0,0 +1,22 @@
+package reader
+
+import "hp90epc/model"
+
+// unitRenderer schreibt Measurement.Unit im konfigurierten Stil um, bevor die Messung an
+// LatestBuffer und Logger geht – so zeigen Live-JSON und Logs dieselbe Schreibweise.
+type unitRenderer struct {
+	style  model.UnitStyle
+	latest LatestSetter
+	logger Logger
+}
+
+// Set erfüllt LatestSetter für RunLoop, SimSource bzw. frameLimiter.
+func (u *unitRenderer) Set(m *model.Measurement) {
+	u.style.Apply(m)
+	if u.latest != nil {
+		u.latest.Set(m)
+	}
+	if u.logger != nil {
+		u.logger.Push(m)
+	}
+}
@@ -0,0 +1,56 @@
+package reader
+
+import (
+	"context"
+	"log/slog"
+	"time"
+)
+
+// watchdogTick: so oft prüft der Watchdog den Status.
+const watchdogTick = time.Second
+
+// SetWatchdog startet den Reader neu, wenn der Port offen ist, aber länger als d kein Frame kam
+// (z.B. ein hängender Treiber, der Read nie zurückkehren lässt); 0 = aus. Wirkt sofort.
+func (m *Manager) SetWatchdog(d time.Duration) {
+	if d < 0 {
+		d = 0
+	}
+	m.mu.Lock()
+	defer m.mu.Unlock()
+	m.watchdog = d
+}
+
+// runWatchdog läuft je Start neben dem RunLoop der Generation gen und endet mit ctx.
+func (m *Manager) runWatchdog(ctx context.Context, gen int, port string, baud int) {
+	t := time.NewTicker(watchdogTick)
+	defer t.Stop()
+	for {
+		select {
+		case <-ctx.Done():
+			return
+		case <-t.C:
+		}
+
+		m.mu.Lock()
+		timeout, st := m.watchdog, m.status
+		if timeout <= 0 || gen != m.gen || !st.PortOpen {
+			m.mu.Unlock()
+			continue
+		}
+		// seit dem Öffnen noch kein Frame: ab dem Öffnen zählen
+		last := st.LastFrameAt
+		if st.openedAt.After(last) {
+			last = st.openedAt
+		}
+		if time.Since(last) < timeout {
+			m.mu.Unlock()
+			continue
+		}
+		m.status.WatchdogRestarts++
+		m.mu.Unlock()
+
+		slog.Warn("watchdog: no frames, restarting reader", "port", port, "last_frame", st.LastFrameAt, "timeout", timeout)
+		_ = m.StartCtx(m.parentCtx(), port, baud)
+		return
+	}
+}
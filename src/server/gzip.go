@@ -0,0 +1,151 @@
+package server
+
+import (
+	"compress/gzip"
+	"net/http"
+	"strings"
+)
+
+// gzipMinSize: kleinere Antworten lohnen die Kompression nicht.
+const gzipMinSize = 1024
+
+// withGzip komprimiert Text-/JSON-/CSV-Antworten für Clients mit Accept-Encoding: gzip.
+// Die ersten gzipMinSize Bytes werden gepuffert, um über die Kompression zu entscheiden;
+// Streams (text/event-stream) und bereits kodierte Antworten gehen unverändert durch.
+func withGzip(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodHead || !acceptsGzip(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Accept-Encoding")
+		gw := &gzipWriter{ResponseWriter: w}
+		defer gw.close()
+		next.ServeHTTP(gw, r)
+	})
+}
+
+func acceptsGzip(r *http.Request) bool {
+	for _, part := range strings.Split(r.Header.Get("Accept-Encoding"), ",") {
+		enc, q, _ := strings.Cut(strings.TrimSpace(part), ";")
+		if strings.TrimSpace(enc) == "gzip" && strings.TrimSpace(q) != "q=0" {
+			return true
+		}
+	}
+	return false
+}
+
+func compressible(contentType string) bool {
+	ct, _, _ := strings.Cut(contentType, ";")
+	ct = strings.TrimSpace(strings.ToLower(ct))
+	switch {
+	case ct == "text/event-stream":
+		return false
+	case strings.HasPrefix(ct, "text/"):
+		return true
+	}
+	switch ct {
+	case "application/json", "application/javascript", "application/x-ndjson",
+		"application/manifest+json", "image/svg+xml":
+		return true
+	}
+	return false
+}
+
+type gzipWriter struct {
+	http.ResponseWriter
+
+	status  int
+	buf     []byte
+	decided bool
+	gz      *gzip.Writer
+}
+
+func (g *gzipWriter) WriteHeader(code int) {
+	if g.decided || g.status != 0 {
+		return
+	}
+	g.status = code
+	// Antworten ohne Body direkt durchreichen
+	if code == http.StatusNoContent || code == http.StatusNotModified || code < 200 {
+		g.decide(false)
+	}
+}
+
+func (g *gzipWriter) Write(p []byte) (int, error) {
+	if !g.decided {
+		g.buf = append(g.buf, p...)
+		if len(g.buf) < gzipMinSize {
+			return len(p), nil
+		}
+		if err := g.decide(true); err != nil {
+			return 0, err
+		}
+		return len(p), nil
+	}
+	if g.gz != nil {
+		return g.gz.Write(p)
+	}
+	return g.ResponseWriter.Write(p)
+}
+
+// decide legt Kompression ja/nein fest, schreibt die Header und den Puffer.
+func (g *gzipWriter) decide(wantGzip bool) error {
+	g.decided = true
+	h := g.Header()
+	if h.Get("Content-Type") == "" && len(g.buf) > 0 {
+		h.Set("Content-Type", http.DetectContentType(g.buf))
+	}
+	if wantGzip && h.Get("Content-Encoding") == "" && compressible(h.Get("Content-Type")) {
+		h.Del("Content-Length")
+		h.Set("Content-Encoding", "gzip")
+		g.gz = gzip.NewWriter(g.ResponseWriter)
+	}
+	if g.status == 0 {
+		g.status = http.StatusOK
+	}
+	g.ResponseWriter.WriteHeader(g.status)
+
+	buf := g.buf
+	g.buf = nil
+	if len(buf) == 0 {
+		return nil
+	}
+	if g.gz != nil {
+		_, err := g.gz.Write(buf)
+		return err
+	}
+	_, err := g.ResponseWriter.Write(buf)
+	return err
+}
+
+// Flush: Streaming-Handler entscheiden damit vor Erreichen der Mindestgröße.
+func (g *gzipWriter) Flush() {
+	if !g.decided {
+		_ = g.decide(true)
+	}
+	if g.gz != nil {
+		_ = g.gz.Flush()
+	}
+	if f, ok := g.ResponseWriter.(http.Flusher); ok {
+		f.Flush()
+	}
+}
+
+// Unwrap für http.ResponseController.
+func (g *gzipWriter) Unwrap() http.ResponseWriter {
+	return g.ResponseWriter
+}
+
+func (g *gzipWriter) close() {
+	if !g.decided {
+		if g.status == 0 && len(g.buf) == 0 {
+			// Handler hat nichts geschrieben: net/http schreibt selbst 200
+			return
+		}
+		_ = g.decide(false)
+	}
+	if g.gz != nil {
+		_ = g.gz.Close()
+	}
+}
@@ -0,0 +1,78 @@
+package server
+
+import (
+	"net/http"
+	"strconv"
+	"time"
+
+	"hp90epc/model"
+)
+
+// Wartezeit von /api/live/poll ohne ?timeout bzw. höchstens; danach 304.
+const (
+	defaultPollTimeout = 25 * time.Second
+	maxPollTimeout     = 60 * time.Second
+)
+
+// livePoll blockiert, bis eine Messung mit anderer Sequenznummer als ?since vorliegt,
+// und liefert sie samt Sequenz: { "seq": 42, "measurement": {...} }.
+// Ohne neue Messung innerhalb ?timeout (Default 25s) kommt 304; bricht der Client ab, endet der Handler sofort.
+func livePoll(app App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		q := r.URL.Query()
+		var since uint64
+		if s := q.Get("since"); s != "" {
+			v, err := strconv.ParseUint(s, 10, 64)
+			if err != nil {
+				http.Error(w, "since must be a sequence number", http.StatusBadRequest)
+				return
+			}
+			since = v
+		}
+		timeout := defaultPollTimeout
+		if s := q.Get("timeout"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 || d > maxPollTimeout {
+				http.Error(w, "timeout must be > 0 and <= "+maxPollTimeout.String(), http.StatusBadRequest)
+				return
+			}
+			timeout = d
+		}
+
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+
+		// erst abonnieren, dann prüfen: ein Set dazwischen geht so nicht verloren
+		wake := make(chan struct{}, 1)
+		cancel := dev.SubscribeLive(func(*model.Measurement) {
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		})
+		defer cancel()
+
+		deadline := time.NewTimer(timeout)
+		defer deadline.Stop()
+		for {
+			// seq < since: Server wurde neu gestartet, der Client bekommt gleich den aktuellen Stand
+			if m, seq := dev.GetLatestSeq(); m != nil && seq != since {
+				sendJSON(w, struct {
+					Seq         uint64    `json:"seq"`
+					Measurement *liveView `json:"measurement"`
+				}{seq, newLiveView(m, app.GetConfig().RawIncluded())})
+				return
+			}
+			select {
+			case <-wake:
+			case <-deadline.C:
+				w.WriteHeader(http.StatusNotModified)
+				return
+			case <-r.Context().Done():
+				return
+			}
+		}
+	}
+}
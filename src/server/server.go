@@ -1,32 +1,119 @@
 package server
 
 import (
+	"archive/zip"
+	"bytes"
+	"context"
+	"crypto/subtle"
+	"crypto/tls"
+	"encoding/base64"
+	"encoding/hex"
 	"encoding/json"
+	"errors"
 	"fmt"
+	"html"
 	"io"
 	"io/fs"
-	"log"
+	"log/slog"
+	"net"
 	"net/http"
+	"os"
+	"path"
 	"strconv"
+	"strings"
+	"time"
 
+	"hp90epc/alarm"
 	"hp90epc/assets"
+	"hp90epc/buildinfo"
+	"hp90epc/config"
 	"hp90epc/logging"
 	"hp90epc/model"
 	"hp90epc/reader"
 )
 
-type App interface {
+// Device: Live-Daten eines einzelnen Messgeräts.
+type Device interface {
 	GetLatest() *model.Measurement
-
+	// GetLatestSeq: letzte Messung mit ihrer Sequenznummer für /api/live/poll; eingefroren
+	// der festgehaltene Wert mit der Sequenz beim Einfrieren
+	GetLatestSeq() (*model.Measurement, uint64)
+	// SubscribeLive meldet fn für jede neue Messung an (Aufruf im Reader-Goroutine, darf nicht blockieren)
+	SubscribeLive(fn func(*model.Measurement)) (cancel func())
+	GetHistory(n int) []model.Sample
+	// GetByMode: letzte Messung je Einheit+Modus, z.B. "V DC", "Ohm"
+	GetByMode() map[string]*model.Measurement
+	GetAlarmStatus() []alarm.Status
 	GetReaderStatus() reader.Status
+	// GetEvents: die letzten n Funktionswechsel, älteste zuerst; ResetEvents leert den Puffer
+	GetEvents(n int) []model.ModeEvent
+	ResetEvents()
+	// GetModeStats: Anzahl Messungen je Einheit+Modus seit Start bzw. ResetReaderStats
+	GetModeStats() model.ModeStats
+	// ResetReaderStats nullt die Frame-/Resync-Summen, die Modus-Zähler und den letzten Fehler
+	ResetReaderStats()
+	// Software-REL: SetRel nimmt die aktuelle Messung als Nullpunkt (model.ErrNoRelValue ohne Zahl)
+	SetRel() (model.RelState, error)
+	ClearRel()
+	GetRel() model.RelState
+	// Freeze hält die Anzeige an: GetLatest und GetLatestSeq (und damit /api/live, Poll und SSE)
+	// liefern bis Unfreeze den Wert dieses Moments, Reader und Logger laufen weiter
+	// (model.ErrNoFreezeValue ohne Messung)
+	Freeze() (model.FreezeState, error)
+	Unfreeze()
+	GetFreeze() model.FreezeState
+}
+
+type App interface {
+	// App selbst ist das Standardgerät; weitere über ?dev=<id>
+	Device
+	DeviceIDs() []string
+	LookupDevice(id string) (Device, bool)
+
+	GetConfig() config.Config
+	UpdateConfig(next config.Config) (restartRequired []string, err error)
+
+	ListProfiles() ([]string, error)
+	GetProfile(name string) (config.Config, error)
+	SaveProfile(name string, c config.Config) error
+	ActivateProfile(name string) (restartRequired []string, err error)
+
+	DecodeFrame(frame []byte) (*model.Measurement, error)
 	SetDevice(port string, baud int) error
+	ListPorts() ([]string, error)
+	// TestDevice probt port/baud mit eigener Quelle, ohne die laufenden Reader zu stören
+	// (reader.ErrPortBusy, wenn ein Reader den Port offen hat)
+	TestDevice(ctx context.Context, port string, baud int, timeout time.Duration) (reader.TestResult, error)
+	SetStaleAfter(ms int) error
 
-	GetLogStatus() logging.LogStatus
+	// LogFiles des Standardgeräts; die anderer Geräte über LookupLogFiles bzw. ?dev=<id>
+	LogFiles
+	LookupLogFiles(id string) (LogFiles, bool)
 	LogStart() (logging.LogStatus, error)
 	LogStop() (logging.LogStatus, error)
+	LogPause() (logging.LogStatus, error)
+	LogResume() (logging.LogStatus, error)
+	LogSnapshot() (*model.Measurement, error)
+	LogSetDir(dir string) (logging.LogStatus, error)
 	LogSetInterval(ms int) error
+	// LogSetNote: Notiz der laufenden bzw. nächsten Logging-Session
+	LogSetNote(note string) (logging.LogStatus, error)
+}
+
+// LogFiles: Status und Dateien des Loggers eines Geräts; jedes Gerät loggt in sein eigenes Verzeichnis.
+type LogFiles interface {
+	GetLogStatus() logging.LogStatus
 	LogListFiles() ([]string, error)
+	LogListFilesWithNotes() ([]logging.FileNote, error)
+	LogListFilesDetailed() ([]logging.FileInfo, error)
 	LogReadFile(name string) ([]byte, error)
+	// LogReadFileRepaired: CSV ohne unvollständige letzte Zeile (logging.ErrNotCSV sonst)
+	LogReadFileRepaired(name string) ([]byte, logging.Validation, error)
+	LogValidateFile(name string) (logging.Validation, error)
+	LogOpenFile(name string) (io.ReadCloser, error)
+	// LogOpenRange: CSV-Datei für einen Zeitfilter öffnen (logging.ErrNoTimestamp ohne timestamp-Spalte)
+	LogOpenRange(name string, from, to time.Time) (*logging.CSVRange, error)
+	LogDeleteFile(name string) error
 	LogTail(name string, maxLines int) ([]string, error)
 }
 
@@ -35,30 +122,609 @@ func sendJSON(w http.ResponseWriter, v any) {
 	_ = json.NewEncoder(w).Encode(v)
 }
 
-func Start(addr string, app App) error {
+// Server hält den http.Server, damit er beim Beenden sauber heruntergefahren werden kann.
+type Server struct {
+	srv *http.Server
+	// socket: Pfad des Unix-Sockets bei addr "unix:<pfad>", sonst leer
+	socket string
+
+	// HTTPS: Zertifikatsdateien oder selbstsigniertes Zertifikat
+	certFile, keyFile string
+	selfSigned        bool
+}
+
+// UnixPrefix kennzeichnet eine Listen-Adresse als Unix-Domain-Socket, z.B. "unix:/run/hp90epc.sock".
+const UnixPrefix = "unix:"
+
+// UnixSocketPath liefert den Socket-Pfad, wenn addr mit UnixPrefix beginnt.
+func UnixSocketPath(addr string) (string, bool) {
+	return strings.CutPrefix(strings.TrimSpace(addr), UnixPrefix)
+}
+
+// Options: optionale Server-Einstellungen aus der Config.
+type Options struct {
+	AuthToken     string
+	BasicAuthUser string
+	BasicAuthPass string
+	// auch GET/HEAD auf /api/* absichern
+	AuthProtectReads bool
+
+	CORSOrigins []string
+
+	// HTTPS, wenn beide Dateien gesetzt sind; sonst TLSSelfSigned = Zertifikat beim Start erzeugen
+	TLSCertFile   string
+	TLSKeyFile    string
+	TLSSelfSigned bool
+
+	// Timeouts des http.Server und Größenlimit für Request-Bodies; 0 = Default (siehe Default*)
+	ReadTimeout  time.Duration
+	WriteTimeout time.Duration
+	IdleTimeout  time.Duration
+	MaxBodyBytes int64
+
+	// BasePath: UI und API unter diesem Präfix ausliefern, z.B. "/hp90epc" hinter einem Reverse-Proxy
+	BasePath string
+}
+
+// CleanBasePath normalisiert einen Basispfad auf "/a/b" (ohne Slash am Ende); "" und "/" = Wurzel.
+func CleanBasePath(p string) string {
+	p = strings.TrimSpace(p)
+	if p == "" {
+		return ""
+	}
+	p = path.Clean("/" + p)
+	if p == "/" {
+		return ""
+	}
+	return p
+}
+
+// Defaults für Options: genug für langsame Clients und große Downloads, aber keine ewig offenen Verbindungen.
+const (
+	DefaultReadTimeout  = 15 * time.Second
+	DefaultWriteTimeout = 60 * time.Second
+	DefaultIdleTimeout  = 120 * time.Second
+	DefaultMaxBodyBytes = 1 << 20
+)
+
+// streamingPaths laufen länger als WriteTimeout (SSE, Long-Polling, Downloads) und setzen
+// ihre Schreib-Deadline selbst bzw. gar nicht.
+var streamingPaths = map[string]bool{
+	"/api/live/stream":      true,
+	"/api/live/poll":        true,
+	"/api/log/file":         true,
+	"/api/log/download-all": true,
+}
+
+// uiContentTypes: Typen, die Go (bzw. das System) nicht zuverlässig kennt; ohne den richtigen
+// Typ verweigern Browser Manifest und Service Worker.
+var uiContentTypes = map[string]string{
+	".webmanifest": "application/manifest+json",
+	".ico":         "image/x-icon",
+	".js":          "text/javascript; charset=utf-8",
+}
+
+// TLS meldet, ob mit diesen Optionen HTTPS ausgeliefert wird.
+func (o Options) TLS() bool {
+	return (o.TLSCertFile != "" && o.TLSKeyFile != "") || o.TLSSelfSigned
+}
+
+func New(addr string, app App, opts Options) *Server {
 	mux := http.NewServeMux()
 
+	// --- API: config
+	mux.HandleFunc("/api/config", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			sendJSON(w, app.GetConfig().Redacted())
+		case http.MethodPost:
+			// partielle Config: nur gesetzte Felder überschreiben die aktuelle
+			next := app.GetConfig()
+			if !decodeJSON(w, r, &next) {
+				return
+			}
+			if err := next.Validate(); err != nil {
+				http.Error(w, "invalid config:\n"+err.Error(), http.StatusBadRequest)
+				return
+			}
+			restart, err := app.UpdateConfig(next)
+			if err != nil {
+				http.Error(w, fmt.Sprintf("update config: %v", err), http.StatusInternalServerError)
+				return
+			}
+			sendJSON(w, map[string]any{
+				"config":           app.GetConfig().Redacted(),
+				"restart_required": restart,
+			})
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// --- API: Alarm-Regeln (Teil der Config)
+	mux.HandleFunc("/api/alarms", func(w http.ResponseWriter, r *http.Request) {
+		type alarmConfig struct {
+			Rules      []config.AlarmRule `json:"rules"`
+			Webhook    string             `json:"webhook"`
+			DebounceMs int                `json:"debounce_ms"`
+		}
+		current := func() alarmConfig {
+			c := app.GetConfig()
+			return alarmConfig{Rules: c.Alarms, Webhook: c.AlarmWebhook, DebounceMs: c.AlarmDebounceMs}
+		}
+		switch r.Method {
+		case http.MethodGet:
+			sendJSON(w, current())
+		case http.MethodPost:
+			req := current()
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			next := app.GetConfig()
+			next.Alarms, next.AlarmWebhook, next.AlarmDebounceMs = req.Rules, req.Webhook, req.DebounceMs
+			if err := next.Validate(); err != nil {
+				http.Error(w, "invalid alarms:\n"+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if _, err := app.UpdateConfig(next); err != nil {
+				http.Error(w, fmt.Sprintf("update alarms: %v", err), http.StatusInternalServerError)
+				return
+			}
+			sendJSON(w, current())
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	mux.HandleFunc("/api/alarms/status", func(w http.ResponseWriter, r *http.Request) {
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		rules := dev.GetAlarmStatus()
+		active := false
+		for _, s := range rules {
+			active = active || s.Active
+		}
+		sendJSON(w, map[string]any{
+			"active": active,
+			"rules":  rules,
+		})
+	})
+
+	// --- API: benannte Profile
+	mux.HandleFunc("/api/profiles", func(w http.ResponseWriter, r *http.Request) {
+		names, err := app.ListProfiles()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("list profiles: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, map[string]any{
+			"profiles": names,
+			"active":   app.GetConfig().Profile,
+		})
+	})
+
+	mux.HandleFunc("/api/profiles/activate", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Name string `json:"name"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		restart, err := app.ActivateProfile(req.Name)
+		if err != nil {
+			writeProfileError(w, "activate profile", err)
+			return
+		}
+		sendJSON(w, map[string]any{
+			"config":           app.GetConfig().Redacted(),
+			"restart_required": restart,
+		})
+	})
+
+	// GET liefert ein Profil, PUT legt es an/überschreibt es (partiell, Basis: bestehendes Profil oder aktuelle Config)
+	mux.HandleFunc("/api/profiles/", func(w http.ResponseWriter, r *http.Request) {
+		name := strings.TrimPrefix(r.URL.Path, "/api/profiles/")
+		switch r.Method {
+		case http.MethodGet:
+			p, err := app.GetProfile(name)
+			if err != nil {
+				writeProfileError(w, "load profile", err)
+				return
+			}
+			sendJSON(w, p.Redacted())
+		case http.MethodPut:
+			base, err := app.GetProfile(name)
+			if errors.Is(err, config.ErrProfileNotFound) {
+				base, err = app.GetConfig(), nil
+			}
+			if err != nil {
+				writeProfileError(w, "load profile", err)
+				return
+			}
+			if !decodeJSON(w, r, &base) {
+				return
+			}
+			if err := base.Validate(); err != nil {
+				http.Error(w, "invalid config:\n"+err.Error(), http.StatusBadRequest)
+				return
+			}
+			if err := app.SaveProfile(name, base); err != nil {
+				writeProfileError(w, "save profile", err)
+				return
+			}
+			base.Profile = name
+			sendJSON(w, base.Redacted())
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
 	// --- API: live
 	mux.HandleFunc("/api/live", func(w http.ResponseWriter, r *http.Request) {
-		// Wenn Reader nicht connected ist: kein "live"
-		st := app.GetReaderStatus()
-		if !st.Connected {
+		// Wenn Reader nicht connected ist: kein "live" – außer innerhalb der Grace-Periode,
+		// dann den letzten Wert mit "stale": true (kurze Wackler sollen die Anzeige nicht leeren)
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		m, stale := liveMeasurement(app, dev, dev.GetReaderStatus())
+		if m == nil {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
+		// ?debug=1: bei "????" die Stellen, die keinem Ziffernmuster entsprachen
+		var diag *model.DecodeDiag
+		if r.URL.Query().Get("debug") == "1" {
+			diag = m.Diag
+		}
+		sendJSON(w, struct {
+			*liveView
+			Stale       bool              `json:"stale"`
+			Frozen      bool              `json:"frozen,omitempty"`
+			RelValue    *float64          `json:"rel_value,omitempty"`
+			Segments    *model.Segments   `json:"segments,omitempty"`
+			Diagnostics *model.DecodeDiag `json:"diagnostics,omitempty"`
+		}{newLiveView(m, app.GetConfig().RawIncluded()), stale, dev.GetFreeze().Active, dev.GetRel().Apply(m), segmentsFor(r, m), diag})
+	})
+
+	// --- API: Anzeige einfrieren (serverseitig, unabhängig von der HOLD-Taste)
+	mux.HandleFunc("/api/live/freeze", func(w http.ResponseWriter, r *http.Request) {
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			sendJSON(w, dev.GetFreeze())
+		case http.MethodPost:
+			st, err := dev.Freeze()
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusConflict)
+				return
+			}
+			sendJSON(w, st)
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+	mux.HandleFunc("/api/live/unfreeze", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		dev.Unfreeze()
+		sendJSON(w, dev.GetFreeze())
+	})
 
-		m := app.GetLatest()
+	// --- API: Software-REL (Nullpunkt je Gerät, unabhängig von der REL-Taste)
+	mux.HandleFunc("/api/rel", func(w http.ResponseWriter, r *http.Request) {
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		sendJSON(w, dev.GetRel())
+	})
+	mux.HandleFunc("/api/rel/set", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		st, err := dev.SetRel()
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		sendJSON(w, st)
+	})
+	mux.HandleFunc("/api/rel/clear", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		dev.ClearRel()
+		sendJSON(w, dev.GetRel())
+	})
+
+	// --- API: Status und Messung in einer Antwort, immer 200 (measurement null statt 204)
+	mux.HandleFunc("/api/live/full", func(w http.ResponseWriter, r *http.Request) {
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		st := dev.GetReaderStatus()
+		m, stale := liveMeasurement(app, dev, st)
+		sendJSON(w, struct {
+			Connected   bool          `json:"connected"`
+			Stale       bool          `json:"stale"`
+			Status      reader.Status `json:"status"`
+			Measurement *liveView     `json:"measurement"`
+		}{st.Connected, stale, st, newLiveView(m, app.GetConfig().RawIncluded())})
+	})
+
+	// --- API: Live-Stream (SSE), optional dezimiert mit ?hz=N
+	mux.HandleFunc("/api/live/stream", liveStream(app))
+
+	// --- API: Long-Polling für Clients ohne SSE, ?since=<seq>
+	mux.HandleFunc("/api/live/poll", livePoll(app))
+
+	// --- API: letzte Messung je Einheit+Modus
+	mux.HandleFunc("/api/live/by-mode", func(w http.ResponseWriter, r *http.Request) {
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		raw := app.GetConfig().RawIncluded()
+		out := map[string]*liveView{}
+		for k, m := range dev.GetByMode() {
+			out[k] = newLiveView(m, raw)
+		}
+		sendJSON(w, out)
+	})
+
+	// --- API: Anzahl Messungen je Einheit+Modus (Plausibilitätscheck einer Sitzung)
+	mux.HandleFunc("/api/stats/modes", func(w http.ResponseWriter, r *http.Request) {
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		sendJSON(w, dev.GetModeStats())
+	})
+
+	// --- API: Funktionswechsel (Drehschalter), ?n=50; DELETE leert den Puffer
+	mux.HandleFunc("/api/events", func(w http.ResponseWriter, r *http.Request) {
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		switch r.Method {
+		case http.MethodGet:
+			n := 50
+			if s := r.URL.Query().Get("n"); s != "" {
+				v, err := strconv.Atoi(s)
+				if err != nil || v <= 0 {
+					http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+					return
+				}
+				n = v
+			}
+			sendJSON(w, dev.GetEvents(n))
+		case http.MethodDelete:
+			dev.ResetEvents()
+			sendJSON(w, dev.GetEvents(0))
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// --- API: Änderungsrate (pro Sekunde) über ?window=5s aus dem Verlauf
+	mux.HandleFunc("/api/live/rate", func(w http.ResponseWriter, r *http.Request) {
+		window := model.DefaultRateWindow
+		if s := r.URL.Query().Get("window"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				http.Error(w, "invalid window (e.g. 5s, 500ms)", http.StatusBadRequest)
+				return
+			}
+			window = d
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		sendJSON(w, model.RateOf(dev.GetHistory(0), window))
+	})
+
+	// --- API: gleitender Mittelwert über ?window=2s aus dem Verlauf
+	mux.HandleFunc("/api/live/smoothed", func(w http.ResponseWriter, r *http.Request) {
+		window := model.DefaultSmoothWindow
+		if s := r.URL.Query().Get("window"); s != "" {
+			d, err := time.ParseDuration(s)
+			if err != nil || d <= 0 {
+				http.Error(w, "invalid window (e.g. 2s, 500ms)", http.StatusBadRequest)
+				return
+			}
+			window = d
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		sendJSON(w, model.SmoothOf(dev.GetHistory(0), window))
+	})
+
+	// --- API: aktueller Wert in anderer Einheit (°C↔°F, SI-Präfixe)
+	mux.HandleFunc("/api/live/convert", func(w http.ResponseWriter, r *http.Request) {
+		target := r.URL.Query().Get("unit")
+		if target == "" {
+			http.Error(w, "missing unit", http.StatusBadRequest)
+			return
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		// eingefroren wie /api/live: der festgehaltene Wert, auch ohne Verbindung
+		m, _, frozen := frozenMeasurement(dev)
+		if !frozen && dev.GetReaderStatus().Connected {
+			m = dev.GetLatest()
+		}
 		if m == nil {
 			w.WriteHeader(http.StatusNoContent)
 			return
 		}
-		sendJSON(w, m)
+		if m.Value == nil {
+			http.Error(w, "current value is not numeric", http.StatusUnprocessableEntity)
+			return
+		}
+		v, err := model.Convert(*m.Value, m.Unit, target)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("%v: %s -> %s", err, m.Unit, target), http.StatusUnprocessableEntity)
+			return
+		}
+		sendJSON(w, map[string]any{
+			"value":      v,
+			"unit":       target,
+			"from_value": *m.Value,
+			"from_unit":  m.Unit,
+			"t":          m.Timestamp,
+		})
+	})
+
+	// --- API: beliebigen Frame dekodieren (Debugging, Fixtures), hex oder base64
+	mux.HandleFunc("/api/decode", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Hex    string `json:"hex"`
+			Base64 string `json:"base64"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		var (
+			frame []byte
+			err   error
+		)
+		switch {
+		case req.Hex != "":
+			// Trenner wie in "raw" (Leerzeichen) oder ":"/"-" zulassen
+			clean := strings.NewReplacer(" ", "", ":", "", "-", "", "\t", "").Replace(req.Hex)
+			frame, err = hex.DecodeString(clean)
+		case req.Base64 != "":
+			frame, err = base64.StdEncoding.DecodeString(req.Base64)
+		default:
+			http.Error(w, "hex or base64 required", http.StatusBadRequest)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("bad frame encoding: %v", err), http.StatusBadRequest)
+			return
+		}
+		m, err := app.DecodeFrame(frame)
+		if errors.Is(err, reader.ErrInvalidFrame) {
+			http.Error(w, err.Error(), http.StatusUnprocessableEntity)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("decode: %v", err), http.StatusInternalServerError)
+			return
+		}
+		var diag *model.DecodeDiag
+		if r.URL.Query().Get("debug") == "1" {
+			diag = m.Diag
+		}
+		sendJSON(w, struct {
+			*model.Measurement
+			Segments    *model.Segments   `json:"segments,omitempty"`
+			Diagnostics *model.DecodeDiag `json:"diagnostics,omitempty"`
+		}{m, segmentsFor(r, m), diag})
+	})
+
+	// --- API: Verlauf für Trend-Charts
+	mux.HandleFunc("/api/history", func(w http.ResponseWriter, r *http.Request) {
+		n := 300
+		if s := r.URL.Query().Get("n"); s != "" {
+			v, err := strconv.Atoi(s)
+			if err != nil || v <= 0 {
+				http.Error(w, "n must be a positive integer", http.StatusBadRequest)
+				return
+			}
+			n = v
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		sendJSON(w, dev.GetHistory(n))
 	})
 
+	// --- API: OpenAPI-Beschreibung (assets/openapi.json) für swagger-ui, Codegen usw.
+	mux.HandleFunc("/api/openapi.json", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "application/json")
+		_, _ = w.Write(assets.OpenAPI())
+	})
+
+	// --- API: Build-Info
+	mux.HandleFunc("/api/version", func(w http.ResponseWriter, r *http.Request) {
+		sendJSON(w, buildinfo.Get())
+	})
+
+	// --- API: Reader-Zähler zurücksetzen
+	mux.HandleFunc("/api/reader/reset-stats", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		dev.ResetReaderStats()
+		sendJSON(w, dev.GetReaderStatus())
+	})
 
 	// --- API: reader status
+	// Liste aller Geräte mit "id", Standardgerät zuerst (auch ?dev=all); ?dev=<id> nur dessen Status
 	mux.HandleFunc("/api/reader/status", func(w http.ResponseWriter, r *http.Request) {
-		sendJSON(w, app.GetReaderStatus())
+		if id := r.URL.Query().Get("dev"); id == "" || id == "all" {
+			type deviceStatus struct {
+				ID string `json:"id"`
+				reader.Status
+			}
+			out := []deviceStatus{}
+			for _, id := range app.DeviceIDs() {
+				if dev, ok := app.LookupDevice(id); ok {
+					out = append(out, deviceStatus{ID: id, Status: dev.GetReaderStatus()})
+				}
+			}
+			sendJSON(w, out)
+			return
+		}
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		sendJSON(w, dev.GetReaderStatus())
 	})
 
 	// --- API: device port hot-swap
@@ -68,11 +734,10 @@ func Start(addr string, app App) error {
 			return
 		}
 		var req struct {
-			Port string `json:"port"`
-			Baud int    `json:"baud"`
+			Port string      `json:"port"`
+			Baud config.Baud `json:"baud"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "bad json", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
 		if req.Port == "" {
@@ -82,13 +747,93 @@ func Start(addr string, app App) error {
 		if req.Baud == 0 {
 			req.Baud = 2400
 		}
-		if err := app.SetDevice(req.Port, req.Baud); err != nil {
+		if err := config.CheckBaud(int(req.Baud)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		if err := app.SetDevice(req.Port, int(req.Baud)); err != nil {
 			http.Error(w, err.Error(), http.StatusInternalServerError)
 			return
 		}
 		sendJSON(w, app.GetReaderStatus())
 	})
 
+	// --- API: Port testen, bevor er mit /api/device/port übernommen wird
+	mux.HandleFunc("/api/device/test", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Port      string      `json:"port"`
+			Baud      config.Baud `json:"baud"`
+			TimeoutMs int         `json:"timeout_ms"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if req.Port == "" {
+			http.Error(w, "port required", http.StatusBadRequest)
+			return
+		}
+		if req.Baud == 0 {
+			req.Baud = 2400
+		}
+		if req.TimeoutMs == 0 {
+			req.TimeoutMs = 3000
+		}
+		if req.TimeoutMs < 500 || req.TimeoutMs > 10000 {
+			http.Error(w, "timeout_ms must be between 500 and 10000", http.StatusBadRequest)
+			return
+		}
+		if err := config.CheckBaud(int(req.Baud)); err != nil {
+			http.Error(w, err.Error(), http.StatusBadRequest)
+			return
+		}
+		res, err := app.TestDevice(r.Context(), req.Port, int(req.Baud), time.Duration(req.TimeoutMs)*time.Millisecond)
+		if errors.Is(err, reader.ErrPortBusy) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, res)
+	})
+
+	// --- API: Stale-Fenster (ab wann ohne Frames "getrennt" gilt)
+	mux.HandleFunc("/api/device/stale", func(w http.ResponseWriter, r *http.Request) {
+		switch r.Method {
+		case http.MethodGet:
+			sendJSON(w, map[string]int64{"stale_after_ms": app.GetReaderStatus().StaleAfterMs})
+		case http.MethodPost:
+			var req struct {
+				StaleAfterMs int `json:"stale_after_ms"`
+			}
+			if !decodeJSON(w, r, &req) {
+				return
+			}
+			if err := app.SetStaleAfter(req.StaleAfterMs); err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			sendJSON(w, app.GetReaderStatus())
+		default:
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+		}
+	})
+
+	// --- API: verfügbare serielle Ports
+	mux.HandleFunc("/api/device/ports", func(w http.ResponseWriter, r *http.Request) {
+		ports, err := app.ListPorts()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("list ports: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, ports)
+	})
+
 	// --- Logging API
 	mux.HandleFunc("/api/log/status", func(w http.ResponseWriter, r *http.Request) {
 		sendJSON(w, app.GetLogStatus())
@@ -117,22 +862,128 @@ func Start(addr string, app App) error {
 		}
 		sendJSON(w, st)
 	})
+	mux.HandleFunc("/api/log/pause", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		st, err := app.LogPause()
+		if errors.Is(err, logging.ErrNotActive) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("pause logging: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, st)
+	})
+	mux.HandleFunc("/api/log/resume", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		st, err := app.LogResume()
+		if errors.Is(err, logging.ErrNotActive) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("resume logging: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, st)
+	})
+	// --- API: Log-Verzeichnis wechseln (nur bei gestopptem Logging)
+	mux.HandleFunc("/api/log/dir", func(w http.ResponseWriter, r *http.Request) {
+		// GET: konfigurierter Wert (evtl. relativ) und tatsächlich verwendeter absoluter Pfad
+		if r.Method == http.MethodGet {
+			sendJSON(w, map[string]string{
+				"configured": app.GetConfig().LogDir,
+				"resolved":   app.GetLogStatus().Dir,
+			})
+			return
+		}
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Dir string `json:"dir"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		if strings.TrimSpace(req.Dir) == "" {
+			http.Error(w, "dir required", http.StatusBadRequest)
+			return
+		}
+		st, err := app.LogSetDir(req.Dir)
+		if errors.Is(err, logging.ErrLoggingActive) {
+			http.Error(w, err.Error(), http.StatusConflict)
+			return
+		}
+		if err != nil {
+			http.Error(w, fmt.Sprintf("set log dir: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, st)
+	})
+
+	// --- API: Notiz zur Logging-Session (Sidecar <datei>.meta.json)
+	mux.HandleFunc("/api/log/note", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		var req struct {
+			Note string `json:"note"`
+		}
+		if !decodeJSON(w, r, &req) {
+			return
+		}
+		st, err := app.LogSetNote(req.Note)
+		if err != nil {
+			http.Error(w, fmt.Sprintf("set note: %v", err), http.StatusInternalServerError)
+			return
+		}
+		sendJSON(w, st)
+	})
+
+	// --- API: einzelne Messung in die Snapshot-Datei, unabhängig vom Intervall-Logging
+	mux.HandleFunc("/api/log/snapshot", func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodPost {
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+		m, err := app.LogSnapshot()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("snapshot: %v", err), http.StatusInternalServerError)
+			return
+		}
+		if m == nil {
+			http.Error(w, "no measurement available", http.StatusConflict)
+			return
+		}
+		sendJSON(w, m)
+	})
 	mux.HandleFunc("/api/log/interval", func(w http.ResponseWriter, r *http.Request) {
 		if r.Method != http.MethodPost {
 			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
 			return
 		}
+		// 0 = jeder Frame, daher muss das Feld explizit gesetzt sein
 		var req struct {
-			IntervalMs int `json:"interval_ms"`
+			IntervalMs *int `json:"interval_ms"`
 		}
-		if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
-			http.Error(w, "bad json", http.StatusBadRequest)
+		if !decodeJSON(w, r, &req) {
 			return
 		}
-		if req.IntervalMs <= 0 {
-			req.IntervalMs = 1000
+		if req.IntervalMs == nil || *req.IntervalMs < 0 {
+			http.Error(w, "interval_ms must be >= 0 (0 = every frame)", http.StatusBadRequest)
+			return
 		}
-		if err := app.LogSetInterval(req.IntervalMs); err != nil {
+		if err := app.LogSetInterval(*req.IntervalMs); err != nil {
 			http.Error(w, fmt.Sprintf("set interval: %v", err), http.StatusInternalServerError)
 			return
 		}
@@ -140,7 +991,31 @@ func Start(addr string, app App) error {
 	})
 
 	mux.HandleFunc("/api/log/files", func(w http.ResponseWriter, r *http.Request) {
-		files, err := app.LogListFiles()
+		lf, ok := logFilesFor(app, w, r)
+		if !ok {
+			return
+		}
+		// ?detailed=1: [{name, size, mod_time, note}], neueste zuerst
+		if r.URL.Query().Get("detailed") == "1" {
+			files, err := lf.LogListFilesDetailed()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("list files: %v", err), http.StatusInternalServerError)
+				return
+			}
+			sendJSON(w, files)
+			return
+		}
+		// ?notes=1: [{name, note}] statt reiner Namensliste
+		if r.URL.Query().Get("notes") == "1" {
+			files, err := lf.LogListFilesWithNotes()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("list files: %v", err), http.StatusInternalServerError)
+				return
+			}
+			sendJSON(w, files)
+			return
+		}
+		files, err := lf.LogListFiles()
 		if err != nil {
 			http.Error(w, fmt.Sprintf("list files: %v", err), http.StatusInternalServerError)
 			return
@@ -149,21 +1024,187 @@ func Start(addr string, app App) error {
 	})
 
 	mux.HandleFunc("/api/log/file", func(w http.ResponseWriter, r *http.Request) {
+		lf, ok := logFilesFor(app, w, r)
+		if !ok {
+			return
+		}
 		name := r.URL.Query().Get("name")
 		if name == "" {
 			http.Error(w, "missing name", http.StatusBadRequest)
 			return
 		}
-		data, err := app.LogReadFile(name)
+		if r.Method == http.MethodDelete {
+			if err := lf.LogDeleteFile(name); err != nil {
+				logFileError(w, "delete file", err)
+				return
+			}
+			files, err := lf.LogListFiles()
+			if err != nil {
+				http.Error(w, fmt.Sprintf("list files: %v", err), http.StatusInternalServerError)
+				return
+			}
+			sendJSON(w, files)
+			return
+		}
+		// komprimierte Datei: Inhaltstyp nach der inneren Endung, Download ohne ".gz"
+		plain := strings.TrimSuffix(name, logging.GzipSuffix)
+		setHeaders := func() {
+			if strings.HasSuffix(plain, ".jsonl") {
+				w.Header().Set("Content-Type", "application/x-ndjson; charset=utf-8")
+			} else {
+				w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			}
+			if plain != name {
+				w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", plain))
+			}
+		}
+		// ?from=…&to=… (RFC3339): nur Zeilen im Zeitfenster, Kopfzeile bleibt; to ist exklusiv
+		if q := r.URL.Query(); q.Get("from") != "" || q.Get("to") != "" {
+			var from, to time.Time
+			for _, p := range []struct {
+				key string
+				t   *time.Time
+			}{{"from", &from}, {"to", &to}} {
+				if s := q.Get(p.key); s != "" {
+					t, err := time.Parse(time.RFC3339Nano, s)
+					if err != nil {
+						http.Error(w, fmt.Sprintf("bad %s (RFC3339 expected): %v", p.key, err), http.StatusBadRequest)
+						return
+					}
+					*p.t = t
+				}
+			}
+			if !from.IsZero() && !to.IsZero() && !from.Before(to) {
+				http.Error(w, "from must be before to", http.StatusBadRequest)
+				return
+			}
+			rng, err := lf.LogOpenRange(name, from, to)
+			if err != nil {
+				logFileError(w, "open file", err)
+				return
+			}
+			defer rng.Close()
+			w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+			w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", plain))
+			if _, err := rng.Copy(w); err != nil {
+				slog.Warn("log file range", "file", name, "err", err)
+			}
+			return
+		}
+		// ?repair=1: abgeschnittene letzte CSV-Zeile weglassen, damit der Import klappt
+		if r.URL.Query().Get("repair") == "1" {
+			data, v, err := lf.LogReadFileRepaired(name)
+			if err != nil {
+				logFileError(w, "read file", err)
+				return
+			}
+			setHeaders()
+			if v.Repairable {
+				w.Header().Set("X-Log-Repaired-Line", strconv.Itoa(v.FirstBadLine))
+			}
+			_, _ = w.Write(data)
+			return
+		}
+		if plain != name && acceptsGzip(r) {
+			// unverändert ausliefern, der Client entpackt
+			rc, err := lf.LogOpenFile(name)
+			if err != nil {
+				logFileError(w, "open file", err)
+				return
+			}
+			defer rc.Close()
+			setHeaders()
+			w.Header().Set("Content-Encoding", "gzip")
+			_, _ = io.Copy(w, rc)
+			return
+		}
+		data, err := lf.LogReadFile(name)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("read file: %v", err), http.StatusInternalServerError)
+			logFileError(w, "read file", err)
 			return
 		}
-		w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+		setHeaders()
 		_, _ = w.Write(data)
 	})
 
+	mux.HandleFunc("/api/log/download-all", func(w http.ResponseWriter, r *http.Request) {
+		lf, ok := logFilesFor(app, w, r)
+		if !ok {
+			return
+		}
+		files, err := lf.LogListFiles()
+		if err != nil {
+			http.Error(w, fmt.Sprintf("list files: %v", err), http.StatusInternalServerError)
+			return
+		}
+		st := lf.GetLogStatus()
+
+		w.Header().Set("Content-Type", "application/zip")
+		zipName := "hp90epc-logs.zip"
+		if id := r.URL.Query().Get("dev"); id != "" {
+			zipName = "hp90epc-logs-" + id + ".zip"
+		}
+		w.Header().Set("Content-Disposition", fmt.Sprintf("attachment; filename=%q", zipName))
+
+		zw := zip.NewWriter(w)
+		// add kopiert eine Datei ins ZIP; fehlende Dateien werden übersprungen
+		add := func(name string) error {
+			rc, err := lf.LogOpenFile(name)
+			if err != nil {
+				if !errors.Is(err, fs.ErrNotExist) {
+					slog.Warn("zip: open log file", "file", name, "err", err)
+				}
+				return nil
+			}
+			defer rc.Close()
+			fw, err := zw.Create(name)
+			if err == nil {
+				_, err = io.Copy(fw, rc)
+			}
+			return err
+		}
+		for _, name := range files {
+			// aktive Datei wird gerade beschrieben → auslassen statt halbe Zeilen zu kopieren
+			if st.Active && name == st.File {
+				continue
+			}
+			err := add(name)
+			if err == nil {
+				// Session-Notiz gehört zur Datei
+				err = add(name + logging.MetaSuffix)
+			}
+			if err != nil {
+				// Header sind schon raus, nur noch abbrechen möglich
+				slog.Warn("zip: copy log file", "file", name, "err", err)
+				return
+			}
+		}
+		_ = zw.Close()
+	})
+
+	mux.HandleFunc("/api/log/validate", func(w http.ResponseWriter, r *http.Request) {
+		lf, ok := logFilesFor(app, w, r)
+		if !ok {
+			return
+		}
+		name := r.URL.Query().Get("name")
+		if name == "" {
+			http.Error(w, "missing name", http.StatusBadRequest)
+			return
+		}
+		v, err := lf.LogValidateFile(name)
+		if err != nil {
+			logFileError(w, "validate file", err)
+			return
+		}
+		sendJSON(w, v)
+	})
+
 	mux.HandleFunc("/api/log/tail", func(w http.ResponseWriter, r *http.Request) {
+		lf, ok := logFilesFor(app, w, r)
+		if !ok {
+			return
+		}
 		name := r.URL.Query().Get("name")
 		if name == "" {
 			http.Error(w, "missing name", http.StatusBadRequest)
@@ -171,13 +1212,16 @@ func Start(addr string, app App) error {
 		}
 		n := 200
 		if s := r.URL.Query().Get("lines"); s != "" {
-			if v, err := strconv.Atoi(s); err == nil && v > 0 {
-				n = v
+			v, err := strconv.Atoi(s)
+			if err != nil || v <= 0 {
+				http.Error(w, "lines must be a positive integer", http.StatusBadRequest)
+				return
 			}
+			n = v
 		}
-		lines, err := app.LogTail(name, n)
+		lines, err := lf.LogTail(name, n)
 		if err != nil {
-			http.Error(w, fmt.Sprintf("tail file: %v", err), http.StatusInternalServerError)
+			logFileError(w, "tail file", err)
 			return
 		}
 		w.Header().Set("Content-Type", "text/plain; charset=utf-8")
@@ -187,31 +1231,356 @@ func Start(addr string, app App) error {
 		}
 	})
 
-	// UI (embedded)
-	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
-		if r.URL.Path != "/" && r.URL.Path != "/index.html" {
-			http.NotFound(w, r)
-			return
-		}
-		data, err := fs.ReadFile(assets.UI(), "index.html")
+	// UI (embedded): alle Dateien aus assets/ui, unbekannte Nicht-API-Pfade → index.html (SPA-Routing)
+	ui := assets.UI()
+	files := http.FileServer(http.FS(ui))
+	base := CleanBasePath(opts.BasePath)
+	// <base href>: relative Asset- und API-URLs der UI lösen sich so auch unter BasePath
+	// und für tiefe SPA-Pfade korrekt auf
+	baseTag := []byte(`<head>
+    <base href="` + html.EscapeString(base) + `/" />`)
+	serveIndex := func(w http.ResponseWriter) {
+		data, err := fs.ReadFile(ui, "index.html")
 		if err != nil {
 			http.Error(w, "index not found", http.StatusInternalServerError)
 			return
 		}
+		data = bytes.Replace(data, []byte("<head>"), baseTag, 1)
 		w.Header().Set("Content-Type", "text/html; charset=utf-8")
 		_, _ = w.Write(data)
-	})
-	mux.HandleFunc("/hp90epc.css", func(w http.ResponseWriter, r *http.Request) {
-		data, err := fs.ReadFile(assets.UI(), "hp90epc.css")
-		if err != nil {
+	}
+	mux.HandleFunc("/", func(w http.ResponseWriter, r *http.Request) {
+		if strings.HasPrefix(r.URL.Path, "/api/") {
 			http.NotFound(w, r)
 			return
 		}
-		w.Header().Set("Content-Type", "text/css; charset=utf-8")
-		_, _ = w.Write(data)
+		name := strings.TrimPrefix(path.Clean(r.URL.Path), "/")
+		if name == "" || name == "index.html" {
+			serveIndex(w)
+			return
+		}
+		if st, err := fs.Stat(ui, name); err != nil || st.IsDir() {
+			serveIndex(w)
+			return
+		}
+		if ct, ok := uiContentTypes[path.Ext(name)]; ok {
+			w.Header().Set("Content-Type", ct)
+		}
+		if name == "sw.js" {
+			// Service Worker immer neu prüfen lassen, sonst bleiben alte UI-Versionen im Cache hängen
+			w.Header().Set("Cache-Control", "no-cache")
+		}
+		files.ServeHTTP(w, r)
+	})
+
+	sock, _ := UnixSocketPath(addr)
+	orDefault := func(d, def time.Duration) time.Duration {
+		if d <= 0 {
+			return def
+		}
+		return d
+	}
+	s := &Server{
+		srv: &http.Server{
+			Addr:              addr,
+			Handler:           withBasePath(base, withLimits(opts.MaxBodyBytes, withGzip(withCORS(opts.CORSOrigins, withAuth(opts, mux))))),
+			ReadHeaderTimeout: orDefault(opts.ReadTimeout, DefaultReadTimeout),
+			ReadTimeout:       orDefault(opts.ReadTimeout, DefaultReadTimeout),
+			WriteTimeout:      orDefault(opts.WriteTimeout, DefaultWriteTimeout),
+			IdleTimeout:       orDefault(opts.IdleTimeout, DefaultIdleTimeout),
+		},
+		socket: sock,
+	}
+	if opts.TLSCertFile != "" && opts.TLSKeyFile != "" {
+		s.certFile, s.keyFile = opts.TLSCertFile, opts.TLSKeyFile
+	} else {
+		s.selfSigned = opts.TLSSelfSigned
+	}
+	return s
+}
+
+// withBasePath hängt next unter base ein und entfernt das Präfix vor dem Routing;
+// base ohne Slash am Ende leitet auf base+"/" um, alles außerhalb ist 404.
+func withBasePath(base string, next http.Handler) http.Handler {
+	if base == "" {
+		return next
+	}
+	mux := http.NewServeMux()
+	mux.Handle(base+"/", http.StripPrefix(base, next))
+	mux.Handle(base, http.RedirectHandler(base+"/", http.StatusMovedPermanently))
+	return mux
+}
+
+// withLimits begrenzt Request-Bodies auf maxBody Bytes (0 = DefaultMaxBodyBytes) und hebt
+// für streamingPaths die Timeouts des Servers auf.
+func withLimits(maxBody int64, next http.Handler) http.Handler {
+	if maxBody <= 0 {
+		maxBody = DefaultMaxBodyBytes
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Body != nil {
+			r.Body = http.MaxBytesReader(w, r.Body, maxBody)
+		}
+		if streamingPaths[r.URL.Path] {
+			// auch die Lese-Deadline: läuft sie ab, bricht net/http den Request-Context ab
+			rc := http.NewResponseController(w)
+			_ = rc.SetReadDeadline(time.Time{})
+			_ = rc.SetWriteDeadline(time.Time{})
+		}
+		next.ServeHTTP(w, r)
 	})
+}
+
+// decodeJSON liest den Body nach dst; bei Fehler ist die Antwort schon geschrieben
+// (413 bei zu großem Body, sonst 400).
+func decodeJSON(w http.ResponseWriter, r *http.Request, dst any) bool {
+	err := json.NewDecoder(r.Body).Decode(dst)
+	if err == nil {
+		return true
+	}
+	var tooBig *http.MaxBytesError
+	if errors.As(err, &tooBig) {
+		http.Error(w, fmt.Sprintf("request body too large (max %d bytes)", tooBig.Limit), http.StatusRequestEntityTooLarge)
+		return false
+	}
+	// Meldung mitgeben, z.B. "invalid baud rate" aus config.Baud
+	http.Error(w, "bad json: "+err.Error(), http.StatusBadRequest)
+	return false
+}
+
+// withCORS setzt die CORS-Header für erlaubte Origins auf /api/* und beantwortet Preflights.
+// Liegt vor withAuth, weil Browser Preflights ohne Authorization-Header schicken.
+func withCORS(origins []string, next http.Handler) http.Handler {
+	if len(origins) == 0 {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		origin := r.Header.Get("Origin")
+		if origin == "" || !strings.HasPrefix(r.URL.Path, "/api/") {
+			next.ServeHTTP(w, r)
+			return
+		}
+		w.Header().Add("Vary", "Origin")
+		if !originAllowed(origins, origin) {
+			next.ServeHTTP(w, r)
+			return
+		}
+		h := w.Header()
+		h.Set("Access-Control-Allow-Origin", origin)
+		h.Set("Access-Control-Allow-Methods", "GET, POST, PUT, DELETE, OPTIONS")
+		h.Set("Access-Control-Allow-Headers", "Content-Type, Authorization")
+		if r.Method == http.MethodOptions && r.Header.Get("Access-Control-Request-Method") != "" {
+			h.Set("Access-Control-Max-Age", "600")
+			w.WriteHeader(http.StatusNoContent)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func originAllowed(origins []string, origin string) bool {
+	for _, o := range origins {
+		if o == "*" || strings.EqualFold(o, origin) {
+			return true
+		}
+	}
+	return false
+}
+
+// withAuth schützt /api/* mit Token oder Basic Auth, sobald eins davon konfiguriert ist.
+// Ohne Konfiguration bleibt alles offen wie bisher.
+func withAuth(opts Options, next http.Handler) http.Handler {
+	if opts.AuthToken == "" && opts.BasicAuthUser == "" {
+		return next
+	}
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		readOnly := r.Method == http.MethodGet || r.Method == http.MethodHead
+		if strings.HasPrefix(r.URL.Path, "/api/") && (opts.AuthProtectReads || !readOnly) && !authorized(opts, r) {
+			if opts.BasicAuthUser != "" {
+				w.Header().Set("WWW-Authenticate", `Basic realm="hp90epc"`)
+			}
+			http.Error(w, "unauthorized", http.StatusUnauthorized)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+func authorized(opts Options, r *http.Request) bool {
+	if opts.AuthToken != "" {
+		if tok, ok := strings.CutPrefix(r.Header.Get("Authorization"), "Bearer "); ok &&
+			subtle.ConstantTimeCompare([]byte(tok), []byte(opts.AuthToken)) == 1 {
+			return true
+		}
+	}
+	if opts.BasicAuthUser != "" {
+		if u, p, ok := r.BasicAuth(); ok &&
+			subtle.ConstantTimeCompare([]byte(u), []byte(opts.BasicAuthUser)) == 1 &&
+			subtle.ConstantTimeCompare([]byte(p), []byte(opts.BasicAuthPass)) == 1 {
+			return true
+		}
+	}
+	return false
+}
+
+// logFileError bildet Fehler beim Zugriff auf eine Log-Datei auf den Status ab:
+// ungültiger Name 400, fehlt 404, keine Rechte 403, wird beschrieben 409, sonst 500.
+func logFileError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, logging.ErrInvalidName), errors.Is(err, logging.ErrNotCSV), errors.Is(err, logging.ErrNoTimestamp):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, fs.ErrNotExist):
+		http.Error(w, "log file not found", http.StatusNotFound)
+	case errors.Is(err, fs.ErrPermission):
+		http.Error(w, "permission denied", http.StatusForbidden)
+	case errors.Is(err, logging.ErrFileActive):
+		http.Error(w, err.Error(), http.StatusConflict)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+	}
+}
+
+// frozenMeasurement: bei aktivem Freeze der festgehaltene Wert und der Zeitpunkt des Einfrierens.
+// Gemeinsamer Pfad für /api/live, /api/live/convert und den Live-Stream.
+func frozenMeasurement(dev Device) (m *model.Measurement, since time.Time, ok bool) {
+	fs := dev.GetFreeze()
+	if !fs.Active {
+		return nil, time.Time{}, false
+	}
+	return dev.GetLatest(), *fs.Since, true
+}
+
+// liveMeasurement: letzte Messung, solange das Gerät verbunden ist oder die Grace-Periode
+// (live_grace_ms) nach dem Stale-Fenster läuft – dann mit stale = true. Sonst nil.
+func liveMeasurement(app App, dev Device, st reader.Status) (m *model.Measurement, stale bool) {
+	// eingefroren: der festgehaltene Wert, auch wenn das Gerät inzwischen getrennt ist
+	if m, _, ok := frozenMeasurement(dev); ok {
+		return m, false
+	}
+	if !st.Connected {
+		grace := time.Duration(app.GetConfig().LiveGraceMs) * time.Millisecond
+		window := time.Duration(st.StaleAfterMs)*time.Millisecond + grace
+		if grace <= 0 || st.LastFrameAt.IsZero() || time.Since(st.LastFrameAt) > window {
+			return nil, false
+		}
+		stale = true
+	}
+	m = dev.GetLatest()
+	if m == nil {
+		return nil, false
+	}
+	return m, stale
+}
+
+// liveView: Messung für Live-Antworten. Raw überdeckt Measurement.RawHex im JSON,
+// nil lässt "raw" weg (include_raw = false) – schlankere Payloads für schnelle SSE-Clients.
+type liveView struct {
+	*model.Measurement
+	Raw *string `json:"raw,omitempty"`
+}
+
+// newLiveView: nil bleibt nil, damit "measurement": null erhalten bleibt.
+func newLiveView(m *model.Measurement, raw bool) *liveView {
+	if m == nil {
+		return nil
+	}
+	v := &liveView{Measurement: m}
+	if raw {
+		v.Raw = &m.RawHex
+	}
+	return v
+}
+
+// segmentsFor liefert das Segment-Rohbild nur bei ?segments=1, sonst bleibt die Antwort schlank.
+func segmentsFor(r *http.Request, m *model.Measurement) *model.Segments {
+	if r.URL.Query().Get("segments") != "1" {
+		return nil
+	}
+	return &m.Segments
+}
 
-	log.Printf("HTTP server listening on %s", addr)
-	return http.ListenAndServe(addr, mux)
+// deviceFor löst ?dev=<id> auf; ohne Parameter das Standardgerät, unbekannte IDs → 404.
+func deviceFor(app App, w http.ResponseWriter, r *http.Request) (Device, bool) {
+	id := r.URL.Query().Get("dev")
+	dev, ok := app.LookupDevice(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown device %q (known: %s)", id, strings.Join(app.DeviceIDs(), ", ")), http.StatusNotFound)
+	}
+	return dev, ok
 }
 
+// logFilesFor: Log-Dateien des Geräts aus ?dev=<id>; unbekannte IDs beantwortet es mit 404.
+func logFilesFor(app App, w http.ResponseWriter, r *http.Request) (LogFiles, bool) {
+	id := r.URL.Query().Get("dev")
+	lf, ok := app.LookupLogFiles(id)
+	if !ok {
+		http.Error(w, fmt.Sprintf("unknown device %q (known: %s)", id, strings.Join(app.DeviceIDs(), ", ")), http.StatusNotFound)
+	}
+	return lf, ok
+}
+
+func writeProfileError(w http.ResponseWriter, op string, err error) {
+	switch {
+	case errors.Is(err, config.ErrInvalidProfile):
+		http.Error(w, err.Error(), http.StatusBadRequest)
+	case errors.Is(err, config.ErrProfileNotFound):
+		http.Error(w, err.Error(), http.StatusNotFound)
+	default:
+		http.Error(w, fmt.Sprintf("%s: %v", op, err), http.StatusInternalServerError)
+	}
+}
+
+// Start blockiert bis zum Fehler oder bis Shutdown aufgerufen wurde (dann nil).
+func (s *Server) Start() error {
+	tlsOn := s.certFile != "" || s.selfSigned
+	if s.selfSigned {
+		cert, err := selfSignedCert()
+		if err != nil {
+			return fmt.Errorf("self-signed certificate: %w", err)
+		}
+		s.srv.TLSConfig = &tls.Config{Certificates: []tls.Certificate{cert}}
+	}
+
+	ln, err := s.listen(tlsOn)
+	if err != nil {
+		return err
+	}
+	slog.Info("HTTP server listening", "addr", s.srv.Addr, "tls", tlsOn)
+	if tlsOn {
+		err = s.srv.ServeTLS(ln, s.certFile, s.keyFile)
+	} else {
+		err = s.srv.Serve(ln)
+	}
+	if errors.Is(err, http.ErrServerClosed) {
+		return nil
+	}
+	return err
+}
+
+// listen öffnet TCP oder, bei "unix:<pfad>", einen Unix-Socket; eine verwaiste Socket-Datei
+// eines früheren Laufs wird ersetzt.
+func (s *Server) listen(tlsOn bool) (net.Listener, error) {
+	if s.socket == "" {
+		addr := s.srv.Addr
+		if addr == "" {
+			addr = ":http"
+			if tlsOn {
+				addr = ":https"
+			}
+		}
+		return net.Listen("tcp", addr)
+	}
+	if fi, err := os.Lstat(s.socket); err == nil && fi.Mode()&os.ModeSocket != 0 {
+		_ = os.Remove(s.socket)
+	}
+	return net.Listen("unix", s.socket)
+}
+
+func (s *Server) Shutdown(ctx context.Context) error {
+	err := s.srv.Shutdown(ctx)
+	if s.socket != "" {
+		// Socket-Datei nicht liegen lassen, sonst scheitert der nächste Start
+		_ = os.Remove(s.socket)
+	}
+	return err
+}
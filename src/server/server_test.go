@@ -0,0 +1,222 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/fs"
+	"net/http"
+	"net/http/httptest"
+	"slices"
+	"strings"
+	"testing"
+	"time"
+
+	"hp90epc/logging"
+	"hp90epc/model"
+	"hp90epc/reader"
+)
+
+func TestLogFileErrorStatus(t *testing.T) {
+	tests := []struct {
+		err  error
+		want int
+	}{
+		{logging.ErrInvalidName, http.StatusBadRequest},
+		{fmt.Errorf("read: %w", logging.ErrInvalidName), http.StatusBadRequest},
+		{logging.ErrNotCSV, http.StatusBadRequest},
+		{fs.ErrNotExist, http.StatusNotFound},
+		{fs.ErrPermission, http.StatusForbidden},
+		{logging.ErrFileActive, http.StatusConflict},
+		{fmt.Errorf("disk on fire"), http.StatusInternalServerError},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		logFileError(w, "read log", tt.err)
+		if w.Code != tt.want {
+			t.Errorf("%v: status %d, want %d", tt.err, w.Code, tt.want)
+		}
+	}
+}
+
+// fakeApp: nur was die Log-Datei-Handler brauchen; alles andere bleibt nil und würde paniken.
+type fakeApp struct {
+	App
+	logs map[string]fakeLogFiles
+	devs map[string]fakeDevice
+}
+
+func (a fakeApp) LookupDevice(id string) (Device, bool) {
+	if id == "" {
+		id = "usb0"
+	}
+	d, ok := a.devs[id]
+	return d, ok
+}
+
+type fakeDevice struct {
+	Device
+	st     reader.Status
+	latest *model.Measurement
+	freeze model.FreezeState
+}
+
+func (d fakeDevice) GetReaderStatus() reader.Status { return d.st }
+func (d fakeDevice) GetLatest() *model.Measurement  { return d.latest.Clone() }
+func (d fakeDevice) GetFreeze() model.FreezeState   { return d.freeze }
+
+func (a fakeApp) DeviceIDs() []string { return []string{"usb0", "usb1"} }
+
+func (a fakeApp) LookupLogFiles(id string) (LogFiles, bool) {
+	if id == "" {
+		id = "usb0"
+	}
+	lf, ok := a.logs[id]
+	return lf, ok
+}
+
+type fakeLogFiles struct {
+	LogFiles
+	files []string
+}
+
+func (f fakeLogFiles) LogListFiles() ([]string, error) { return f.files, nil }
+
+func (f fakeLogFiles) LogTail(name string, n int) ([]string, error) {
+	return []string{f.files[0] + ":" + name}, nil
+}
+
+func TestLogFilesDeviceSelector(t *testing.T) {
+	app := fakeApp{logs: map[string]fakeLogFiles{
+		"usb0": {files: []string{"main.csv"}},
+		"usb1": {files: []string{"extra.csv"}},
+	}}
+	h := New(":0", app, Options{}).srv.Handler
+
+	tests := []struct {
+		url  string
+		code int
+		body string
+	}{
+		{"/api/log/files", http.StatusOK, `["main.csv"]` + "\n"},
+		{"/api/log/files?dev=usb1", http.StatusOK, `["extra.csv"]` + "\n"},
+		{"/api/log/tail?dev=usb1&name=a.csv", http.StatusOK, "extra.csv:a.csv\n"},
+		{"/api/log/files?dev=nope", http.StatusNotFound, ""},
+		{"/api/log/tail?dev=nope&name=a.csv", http.StatusNotFound, ""},
+		{"/api/log/tail?name=a.csv&lines=0", http.StatusBadRequest, ""},
+		{"/api/log/tail?name=a.csv&lines=abc", http.StatusBadRequest, ""},
+		{"/api/log/tail?name=a.csv&lines=5", http.StatusOK, "main.csv:a.csv\n"},
+		{"/api/log/file?dev=nope&name=a.csv", http.StatusNotFound, ""},
+		{"/api/log/validate?dev=nope&name=a.csv", http.StatusNotFound, ""},
+		{"/api/log/download-all?dev=nope", http.StatusNotFound, ""},
+	}
+	for _, tt := range tests {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, tt.url, nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.url, w.Code, tt.code)
+			continue
+		}
+		if tt.body != "" && w.Body.String() != tt.body {
+			t.Errorf("%s: body %q, want %q", tt.url, w.Body.String(), tt.body)
+		}
+	}
+}
+
+func TestHistoryRejectsBadN(t *testing.T) {
+	h := New(":0", fakeApp{}, Options{}).srv.Handler
+	for _, n := range []string{"0", "-3", "abc", "1.5"} {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/history?n="+n, nil))
+		if w.Code != http.StatusBadRequest {
+			t.Errorf("n=%s: status %d, want 400", n, w.Code)
+		}
+	}
+}
+
+func TestCORSPreflightAllowsProfilePut(t *testing.T) {
+	h := New(":0", fakeApp{}, Options{CORSOrigins: []string{"https://ui.example"}}).srv.Handler
+	r := httptest.NewRequest(http.MethodOptions, "/api/profiles/bench", nil)
+	r.Header.Set("Origin", "https://ui.example")
+	r.Header.Set("Access-Control-Request-Method", http.MethodPut)
+	w := httptest.NewRecorder()
+	h.ServeHTTP(w, r)
+
+	if w.Code != http.StatusNoContent {
+		t.Fatalf("preflight status %d, want 204", w.Code)
+	}
+	if got := w.Header().Get("Access-Control-Allow-Origin"); got != "https://ui.example" {
+		t.Errorf("Allow-Origin = %q", got)
+	}
+	allowed := strings.Split(w.Header().Get("Access-Control-Allow-Methods"), ", ")
+	if !slices.Contains(allowed, http.MethodPut) {
+		t.Errorf("Allow-Methods = %q, want PUT", allowed)
+	}
+}
+
+func TestReaderStatusListsDevices(t *testing.T) {
+	app := fakeApp{devs: map[string]fakeDevice{
+		"usb0": {st: reader.Status{Port: "/dev/usb0", Connected: true}},
+		"usb1": {st: reader.Status{Port: "/dev/usb1"}},
+	}}
+	h := New(":0", app, Options{}).srv.Handler
+	get := func(url string, v any) int {
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, url, nil))
+		if w.Code == http.StatusOK {
+			if err := json.Unmarshal(w.Body.Bytes(), v); err != nil {
+				t.Fatalf("%s: %v", url, err)
+			}
+		}
+		return w.Code
+	}
+
+	for _, url := range []string{"/api/reader/status", "/api/reader/status?dev=all"} {
+		var all []struct {
+			ID   string `json:"id"`
+			Port string `json:"port"`
+		}
+		if code := get(url, &all); code != http.StatusOK {
+			t.Fatalf("%s: status %d", url, code)
+		}
+		if len(all) != 2 || all[0].ID != "usb0" || all[0].Port != "/dev/usb0" || all[1].ID != "usb1" {
+			t.Errorf("%s = %+v, want usb0 then usb1", url, all)
+		}
+	}
+
+	var one reader.Status
+	if code := get("/api/reader/status?dev=usb1", &one); code != http.StatusOK || one.Port != "/dev/usb1" {
+		t.Errorf("dev=usb1: status %d, %+v", code, one)
+	}
+	if code := get("/api/reader/status?dev=nope", &one); code != http.StatusNotFound {
+		t.Errorf("dev=nope: status %d, want 404", code)
+	}
+}
+
+// eingefroren liefert convert wie /api/live den festgehaltenen Wert, auch nach Verbindungsabbruch
+func TestConvertFrozenWhileDisconnected(t *testing.T) {
+	v := 4700.0
+	m := &model.Measurement{Value: &v, Unit: "kOhm"}
+	since := time.Now()
+	tests := []struct {
+		name string
+		dev  fakeDevice
+		code int
+	}{
+		{"verbunden", fakeDevice{st: reader.Status{Connected: true}, latest: m}, http.StatusOK},
+		{"getrennt", fakeDevice{latest: m}, http.StatusNoContent},
+		{"getrennt, eingefroren", fakeDevice{latest: m, freeze: model.FreezeState{Active: true, Since: &since}}, http.StatusOK},
+	}
+	for _, tt := range tests {
+		app := fakeApp{devs: map[string]fakeDevice{"usb0": tt.dev}}
+		h := New(":0", app, Options{}).srv.Handler
+		w := httptest.NewRecorder()
+		h.ServeHTTP(w, httptest.NewRequest(http.MethodGet, "/api/live/convert?unit=Ohm", nil))
+		if w.Code != tt.code {
+			t.Errorf("%s: status %d, want %d", tt.name, w.Code, tt.code)
+			continue
+		}
+		if tt.code == http.StatusOK && !strings.Contains(w.Body.String(), `"value":4700`) {
+			t.Errorf("%s: body %s", tt.name, w.Body.String())
+		}
+	}
+}
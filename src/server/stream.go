@@ -0,0 +1,136 @@
+package server
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"sync"
+	"time"
+
+	"hp90epc/model"
+)
+
+// Abstand der Kommentar-Events (Keepalive + Drop-Zähler) im Live-Stream.
+const streamCommentEvery = 5 * time.Second
+
+// liveStream liefert Messungen als Server-Sent Events. Mit ?hz=N höchstens N Events pro Sekunde:
+// es wird immer die neueste Messung gesendet, Zwischenwerte werden verworfen. Die Subscription
+// puffert nur einen Wert, der Reader wird also nie von langsamen Clients gebremst.
+// Eingefroren (POST /api/live/freeze) kommt der festgehaltene Wert einmal, danach erst wieder nach Unfreeze.
+func liveStream(app App) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		var minGap time.Duration
+		if s := r.URL.Query().Get("hz"); s != "" {
+			hz, err := strconv.ParseFloat(s, 64)
+			if err != nil || hz <= 0 || hz > 1000 {
+				http.Error(w, "hz must be > 0 and <= 1000", http.StatusBadRequest)
+				return
+			}
+			minGap = time.Duration(float64(time.Second) / hz)
+		}
+
+		dev, ok := deviceFor(app, w, r)
+		if !ok {
+			return
+		}
+		// einmal je Verbindung statt je Event
+		raw := app.GetConfig().RawIncluded()
+
+		var (
+			mu      sync.Mutex
+			pending *model.Measurement
+			dropped int
+		)
+		wake := make(chan struct{}, 1)
+		cancel := dev.SubscribeLive(func(m *model.Measurement) {
+			mu.Lock()
+			if pending != nil {
+				dropped++
+			}
+			pending = m
+			mu.Unlock()
+			select {
+			case wake <- struct{}{}:
+			default:
+			}
+		})
+		defer cancel()
+
+		h := w.Header()
+		h.Set("Content-Type", "text/event-stream")
+		h.Set("Cache-Control", "no-cache")
+		h.Set("X-Accel-Buffering", "no")
+		rc := http.NewResponseController(w)
+
+		// write setzt vor jedem Schreiben eine frische Deadline: hängende Clients fliegen raus,
+		// ohne dass eine globale WriteTimeout den Stream beendet.
+		write := func(format string, args ...any) bool {
+			_ = rc.SetWriteDeadline(time.Now().Add(10 * time.Second))
+			if _, err := fmt.Fprintf(w, format, args...); err != nil {
+				return false
+			}
+			return rc.Flush() == nil
+		}
+		if !write("retry: 2000\n\n") {
+			return
+		}
+
+		comment := time.NewTicker(streamCommentEvery)
+		defer comment.Stop()
+		var lastSent, sentFrozen time.Time
+		reported := 0
+
+		for {
+			select {
+			case <-r.Context().Done():
+				return
+			case <-comment.C:
+				mu.Lock()
+				d := dropped
+				mu.Unlock()
+				if !write(": dropped=%d\n\n", d-reported) {
+					return
+				}
+				reported = d
+				continue
+			case <-wake:
+			}
+
+			// Rate-Limit: bis zum nächsten erlaubten Zeitpunkt warten, dabei neuere Werte übernehmen
+			if wait := time.Until(lastSent.Add(minGap)); wait > 0 {
+				t := time.NewTimer(wait)
+				select {
+				case <-r.Context().Done():
+					t.Stop()
+					return
+				case <-t.C:
+				}
+			}
+
+			mu.Lock()
+			m := pending
+			pending = nil
+			mu.Unlock()
+			if fm, since, ok := frozenMeasurement(dev); ok {
+				if since.Equal(sentFrozen) {
+					continue
+				}
+				m, sentFrozen = fm, since
+			} else {
+				sentFrozen = time.Time{}
+			}
+			if m == nil {
+				continue
+			}
+			b, err := json.Marshal(newLiveView(m, raw))
+			if err != nil {
+				continue
+			}
+			if !write("event: measurement\ndata: %s\n\n", b) {
+				return
+			}
+			lastSent = time.Now()
+		}
+	}
+}